@@ -78,6 +78,9 @@ func newRunMonitorCommand() *cobra.Command {
 	monitorOpt := &monitor.Options{
 		Out:    os.Stdout,
 		ErrOut: os.Stderr,
+		Config: monitor.Config{
+			PClusterKubeconfig: os.Getenv("PCLUSTER_KUBECONFIG"),
+		},
 	}
 	cmd := &cobra.Command{
 		Use:   "run-monitor",
@@ -85,6 +88,11 @@ func newRunMonitorCommand() *cobra.Command {
 		Long: templates.LongDesc(`
 		Run a continuous verification process
 
+		With --listen the monitor serves its state as Prometheus metrics on /metrics, the
+		recorded events and conditions as JSON on /events and /conditions, and new events
+		as server-sent events on /events/stream. /events and /conditions accept the from
+		and to RFC 3339 times, the minimum level and a locator regular expression as query
+		parameters.
 		`),
 
 		SilenceUsage:  true,
@@ -93,12 +101,30 @@ func newRunMonitorCommand() *cobra.Command {
 			return monitorOpt.Run()
 		},
 	}
+	cmd.Flags().StringVar(&monitorOpt.ListenAddr, "listen", monitorOpt.ListenAddr, "The address to serve monitor metrics and events on, for example :8080. Nothing is served by default.")
+	cmd.Flags().DurationVar(&monitorOpt.Config.LatencyThresholds.Warning, "latency-warning", monitorOpt.Config.LatencyThresholds.Warning, "Record a warning when the p95 latency of the sampled API requests exceeds this duration. Defaults to 1s.")
+	cmd.Flags().DurationVar(&monitorOpt.Config.LatencyThresholds.Error, "latency-error", monitorOpt.Config.LatencyThresholds.Error, "Record an error when the p95 latency of the sampled API requests exceeds this duration. Defaults to 3s.")
+	cmd.Flags().StringVar(&monitorOpt.Config.RulesFile, "rules", monitorOpt.Config.RulesFile, "A YAML file of rules that change the level of, drop or tag the recorded events by locator and message.")
+	cmd.Flags().StringSliceVar(&monitorOpt.Config.WorkspaceEventPrefixes, "workspace-events", monitorOpt.Config.WorkspaceEventPrefixes, "Record the events of the workspaces whose name starts with one of these prefixes, for example e2e-test-, through the wildcard logical cluster.")
+	cmd.Flags().StringVar(&monitorOpt.Config.PClusterKubeconfig, "pcluster-kubeconfig", monitorOpt.Config.PClusterKubeconfig, "The kubeconfig of the physical cluster to monitor the syncer pods and nodes of. Defaults to $PCLUSTER_KUBECONFIG.")
+	cmd.Flags().StringSliceVar(&monitorOpt.Config.Monitors, "monitors", monitorOpt.Config.Monitors, "The monitor sources to run. '*' runs every source, 'foo' runs the source named foo and '-foo' disables it. Defaults to every source.")
+	cmd.Flags().DurationVar(&monitorOpt.Config.Storage.Retention, "retention", monitorOpt.Config.Storage.Retention, "Discard the events and samples older than this. Everything is kept by default.")
+	cmd.Flags().IntVar(&monitorOpt.Config.Storage.MaxEventsInMemory, "max-events-in-memory", monitorOpt.Config.Storage.MaxEventsInMemory, "The number of events and of samples to keep in memory, older ones are written to --spill-dir or discarded. Unlimited by default.")
+	cmd.Flags().StringVar(&monitorOpt.Config.Storage.SpillDir, "spill-dir", monitorOpt.Config.Storage.SpillDir, "The directory to write the events and samples over --max-events-in-memory to.")
+	cmd.Flags().StringSliceVar(&monitorOpt.Config.LogFiles, "log-file", monitorOpt.Config.LogFiles, "Follow a local log file, such as the log of a kcp server run as a local process, and record the lines matching --log-pattern.")
+	cmd.Flags().StringArrayVar(&monitorOpt.Config.LogPatterns, "log-pattern", monitorOpt.Config.LogPatterns, "A LEVEL=REGEXP pattern of the log lines to record from the log files and syncer pods, for example 'Warning=^E\\d{4} '. Defaults to panics, klog errors and leader election changes.")
+	cmd.Flags().DurationVar(&monitorOpt.Config.WatchBookmarkTimeout, "watch-bookmark-timeout", monitorOpt.Config.WatchBookmarkTimeout, "Record the probe watches as disrupted when they deliver neither events nor bookmarks for this long. Disabled by default, as kcp does not send bookmarks yet.")
+	cmd.Flags().StringSliceVar(&monitorOpt.Config.ShardURLs, "shard-url", monitorOpt.Config.ShardURLs, "The base URL of a shard to monitor the readyz and livez checks of. Defaults to the shards registered in the root workspace.")
+	cmd.Flags().DurationVar(&monitorOpt.Config.AggregationWindow, "aggregate-events", monitorOpt.Config.AggregationWindow, "Collapse the repeated events on a locator that occur within this duration of each other into one interval. Every event is recorded by default.")
 	return cmd
 }
 
 func newRunCommand() *cobra.Command {
 	opt := &testginkgo.Options{
 		Suites: staticSuites,
+		MonitorConfig: monitor.Config{
+			PClusterKubeconfig: os.Getenv("PCLUSTER_KUBECONFIG"),
+		},
 	}
 
 	cmd := &cobra.Command{
@@ -205,6 +231,15 @@ func bindOptions(opt *testginkgo.Options, flags *pflag.FlagSet) {
 	flags.DurationVar(&opt.Timeout, "timeout", opt.Timeout, "Set the maximum time a test can run before being aborted. This is read from the suite by default, but will be 10 minutes otherwise.")
 	flags.BoolVar(&opt.IncludeSuccessOutput, "include-success", opt.IncludeSuccessOutput, "Print output from successful tests.")
 	flags.IntVar(&opt.Parallelism, "max-parallel-tests", opt.Parallelism, "Maximum number of tests running in parallel. 0 defaults to test suite recommended value, which is different in each suite.")
+	flags.DurationVar(&opt.DisruptionThresholds.APIUnavailable, "max-api-disruption", opt.DisruptionThresholds.APIUnavailable, "Fail the synthetic test for API availability if the kcp API is unavailable for longer than this at once. Defaults to 10s.")
+	flags.DurationVar(&opt.DisruptionThresholds.WorkspaceInitializing, "max-workspace-initializing", opt.DisruptionThresholds.WorkspaceInitializing, "Fail the synthetic test for workspace initialization if a workspace stays Initializing for longer than this. Defaults to 2m.")
+	flags.StringVar(&opt.MonitorConfig.RulesFile, "monitor-rules", opt.MonitorConfig.RulesFile, "A YAML file of rules that change the level of, drop or tag the events recorded by the monitor by locator and message.")
+	flags.StringSliceVar(&opt.MonitorConfig.WorkspaceEventPrefixes, "monitor-workspace-events", opt.MonitorConfig.WorkspaceEventPrefixes, "Record the events of the workspaces whose name starts with one of these prefixes, for example e2e-test-, through the wildcard logical cluster.")
+	flags.StringVar(&opt.MonitorConfig.PClusterKubeconfig, "monitor-pcluster-kubeconfig", opt.MonitorConfig.PClusterKubeconfig, "The kubeconfig of the physical cluster to monitor the syncer pods and nodes of. Defaults to $PCLUSTER_KUBECONFIG.")
+	flags.StringSliceVar(&opt.MonitorConfig.Monitors, "monitors", opt.MonitorConfig.Monitors, "The monitor sources to run during the suite. '*' runs every source, 'foo' runs the source named foo and '-foo' disables it. Defaults to every source.")
+	flags.DurationVar(&opt.MonitorConfig.AggregationWindow, "monitor-aggregate-events", opt.MonitorConfig.AggregationWindow, "Collapse the repeated events on a locator that occur within this duration of each other into one interval in the timeline. Every event is recorded by default.")
+	flags.StringSliceVar(&opt.MonitorConfig.LogFiles, "monitor-log-file", opt.MonitorConfig.LogFiles, "Follow a local log file, such as the log of a kcp server run as a local process, and record the lines matching --monitor-log-pattern.")
+	flags.StringArrayVar(&opt.MonitorConfig.LogPatterns, "monitor-log-pattern", opt.MonitorConfig.LogPatterns, "A LEVEL=REGEXP pattern of the log lines to record from the log files and syncer pods. Defaults to panics, klog errors and leader election changes.")
 }
 
 func initProvider(provider string, dryRun bool) error {
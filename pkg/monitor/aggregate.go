@@ -0,0 +1,95 @@
+package monitor
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// repeatedCount matches the occurrence count kube appends to the message of a repeated
+// event, for example "Back-off restarting failed container (57 times)", followed by the
+// known issue a rule tagged the message with, if any.
+var repeatedCount = regexp.MustCompile(`\s*\(\d+ times\)(\s*\(known issue [^)]*\))?$`)
+
+// repeat tracks the occurrences of an event collapsed into it.
+type repeat struct {
+	// count is the number of occurrences including the event itself
+	count int
+	last  time.Time
+}
+
+// normalizeMessage returns the message without the occurrence count, so the count
+// bumps of a kube event compare equal whether or not a rule tagged them.
+func normalizeMessage(message string) string {
+	return repeatedCount.ReplaceAllString(message, "$1")
+}
+
+// SetAggregation collapses a condition recorded after this call into the previous event
+// on the same locator if they have the same level and normalized message and occur
+// within window of each other. The collapsed event is returned by Events as an interval
+// from its first to its last occurrence with the number of occurrences. A zero window,
+// the default, records every condition as its own event.
+func (m *Monitor) SetAggregation(window time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.aggregation = window
+	if m.lastByLocator == nil {
+		m.lastByLocator = make(map[string]*Event)
+		m.repeats = make(map[*Event]*repeat)
+	}
+}
+
+// aggregateLocked returns true if the condition was collapsed into the previous event
+// on its locator, and otherwise tracks event as the previous event on its locator.
+func (m *Monitor) aggregateLocked(event *Event) bool {
+	if m.aggregation == 0 {
+		return false
+	}
+	if last, ok := m.lastByLocator[event.Locator]; ok && last.Level == event.Level && normalizeMessage(last.Message) == normalizeMessage(event.Message) {
+		r, ok := m.repeats[last]
+		if !ok {
+			r = &repeat{count: 1, last: last.At}
+			m.repeats[last] = r
+		}
+		if event.At.Sub(r.last) <= m.aggregation {
+			r.count++
+			r.last = event.At
+			return true
+		}
+	}
+	m.lastByLocator[event.Locator] = event
+	return false
+}
+
+// forgetLocked stops aggregating into the events evicted from memory.
+func (m *Monitor) forgetLocked(events []*Event) {
+	if m.aggregation == 0 {
+		return
+	}
+	for _, event := range events {
+		delete(m.repeats, event)
+		if m.lastByLocator[event.Locator] == event {
+			delete(m.lastByLocator, event.Locator)
+		}
+	}
+}
+
+// aggregatedInterval returns the interval of an event, spanning its occurrences if
+// repeats were collapsed into it.
+func aggregatedInterval(event *Event, repeats map[*Event]repeat) *EventInterval {
+	r, ok := repeats[event]
+	if !ok || r.count < 2 {
+		return &EventInterval{
+			From:      event.At,
+			To:        event.At,
+			Condition: &event.Condition,
+		}
+	}
+	condition := event.Condition
+	condition.Message = fmt.Sprintf("%s (%d times)", normalizeMessage(condition.Message), r.count)
+	return &EventInterval{
+		From:      event.At,
+		To:        r.last,
+		Condition: &condition,
+	}
+}
@@ -0,0 +1,77 @@
+package monitor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMonitor_Aggregation(t *testing.T) {
+	pod := "ns/kcp-syncer-a pod/b node/c"
+	record := func(m *Monitor) {
+		m.Record(Condition{Level: Warning, Locator: pod, Message: "Back-off restarting failed container"})
+		m.Record(Condition{Level: Info, Locator: "kube-apiserver", Message: "Kube API started failing"})
+		m.Record(Condition{Level: Warning, Locator: pod, Message: "Back-off restarting failed container (2 times)"})
+		m.Record(Condition{Level: Warning, Locator: pod, Message: "Back-off restarting failed container (3 times)"})
+		m.Record(Condition{Level: Info, Locator: pod, Message: "container restarted"})
+		m.Record(Condition{Level: Warning, Locator: pod, Message: "Back-off restarting failed container (4 times)"})
+	}
+	messages := func(m *Monitor) []string {
+		var messages []string
+		for _, event := range m.Events(time.Time{}, time.Time{}) {
+			messages = append(messages, event.Message)
+		}
+		return messages
+	}
+
+	raw := NewMonitor()
+	record(raw)
+	if got := messages(raw); len(got) != 6 {
+		t.Errorf("expected every event in raw mode, got %q", got)
+	}
+
+	m := NewMonitor()
+	m.SetAggregation(time.Minute)
+	record(m)
+	want := []string{
+		"Back-off restarting failed container (3 times)",
+		"Kube API started failing",
+		"container restarted",
+		"Back-off restarting failed container (4 times)",
+	}
+	if got := messages(m); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("unexpected aggregated events:\n%q", got)
+	}
+	if events, _ := m.EventsSince(0); len(events) != 4 || events[0].Message != "Back-off restarting failed container" {
+		t.Errorf("expected the first occurrence to be returned since a cursor: %v", events)
+	}
+
+	// occurrences farther apart than the window are not collapsed
+	at := time.Now()
+	m = NewMonitor()
+	m.SetAggregation(time.Minute)
+	for i, offset := range []time.Duration{0, 30 * time.Second, 2 * time.Minute} {
+		if collapsed := m.aggregateLocked(&Event{At: at.Add(offset), Condition: Condition{Locator: pod, Message: "BackOff"}}); collapsed != (i == 1) {
+			t.Errorf("%d: unexpected collapsed=%t", i, collapsed)
+		}
+	}
+}
+
+func TestMonitor_AggregationWithRules(t *testing.T) {
+	rules, err := NewRules(Rule{Message: "^Back-off", Issue: "https://github.com/kcp-dev/kcp/issues/1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMonitor()
+	m.SetRules(rules)
+	m.SetAggregation(time.Minute)
+	pod := "ns/kcp-syncer-a pod/b node/c"
+	m.Record(Condition{Level: Warning, Locator: pod, Message: "Back-off restarting failed container"})
+	m.Record(Condition{Level: Warning, Locator: pod, Message: "Back-off restarting failed container (2 times)"})
+	m.Record(Condition{Level: Warning, Locator: pod, Message: "Back-off restarting failed container (3 times)"})
+
+	events := m.Events(time.Time{}, time.Time{})
+	if len(events) != 1 || events[0].Message != "Back-off restarting failed container (known issue https://github.com/kcp-dev/kcp/issues/1) (3 times)" {
+		t.Errorf("expected the tagged events to be collapsed, got %v", events)
+	}
+}
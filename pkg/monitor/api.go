@@ -12,6 +12,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	clientcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
@@ -21,10 +22,71 @@ import (
 	clientimagev1 "github.com/openshift/client-go/image/clientset/versioned/typed/image/v1"
 )
 
+// Config controls how the sources started by Start report. The zero value selects
+// the defaults.
+type Config struct {
+	// LatencyThresholds are the request latencies above which the request latency
+	// sampler reports conditions.
+	LatencyThresholds LatencyThresholds
+
+	// RulesFile, if set, is a rules file applied to every recorded condition.
+	// See LoadRules for the format.
+	RulesFile string
+
+	// WorkspaceEventPrefixes, if set, records the events of the workspaces whose name
+	// starts with one of the prefixes, and of the workspaces nested in them, through
+	// the wildcard logical cluster. Tests create workspaces prefixed with "e2e-test-".
+	WorkspaceEventPrefixes []string
+
+	// PClusterKubeconfig, if set, is the kubeconfig of the physical cluster the syncers
+	// run on. The pods of the syncer and synced namespaces and the nodes of that cluster
+	// are monitored with locators prefixed with "pcluster/".
+	PClusterKubeconfig string
+
+	// Monitors selects the sources to start by name. "*" selects every source, "foo"
+	// selects the source foo and "-foo" deselects it. An empty list, or one that only
+	// deselects sources, starts from every source.
+	Monitors []string
+
+	// Storage bounds the data the monitor keeps, by default everything is kept in memory.
+	Storage StorageConfig
+	// LogFiles are local log files, for example of a kcp server running as a local
+	// process, whose lines matching LogPatterns are recorded.
+	LogFiles []string
+	// LogPatterns select the log lines recorded from LogFiles and from the syncer pods
+	// on the physical cluster, in the form LEVEL=REGEXP. By default panics, klog errors
+	// and leader election changes are recorded.
+	LogPatterns []string
+
+	// WatchBookmarkTimeout, if set, is how long the probe watches may deliver neither
+	// events nor bookmarks before they are recorded as disrupted. kcp does not send
+	// bookmarks yet, so the check is disabled by default.
+	WatchBookmarkTimeout time.Duration
+
+	// ShardURLs are the base URLs of the shards whose readyz and livez checks are
+	// monitored. By default the shards are the ClusterWorkspaceShards of the root workspace.
+	ShardURLs []string
+
+	// AggregationWindow, if set, collapses the repeated events on a locator that occur
+	// within the window of each other into one interval, see Monitor.SetAggregation.
+	AggregationWindow time.Duration
+}
+
 // Start begins monitoring the cluster referenced by the default kube configuration until
 // context is finished.
-func Start(ctx context.Context) (*Monitor, error) {
-	m := NewMonitor()
+func Start(ctx context.Context, config Config) (*Monitor, error) {
+	m, err := NewMonitorWithStorage(config.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.RulesFile) > 0 {
+		rules, err := LoadRules(config.RulesFile)
+		if err != nil {
+			return nil, err
+		}
+		m.SetRules(rules)
+	}
+	m.SetAggregation(config.AggregationWindow)
 	cfg := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{})
 	clusterConfig, err := cfg.ClientConfig()
 	if err != nil {
@@ -34,18 +96,97 @@ func Start(ctx context.Context) (*Monitor, error) {
 	if err != nil {
 		return nil, err
 	}
+	dynamicClient, err := dynamic.NewForConfig(clusterConfig)
+	if err != nil {
+		return nil, err
+	}
 	// configClient, err := configclientset.NewForConfig(clusterConfig)
 	// if err != nil {
 	// 	return nil, err
 	// }
 
-	if err := startAPIMonitoring(ctx, m, clusterConfig); err != nil {
+	logPatterns, err := parseLogPatterns(config.LogPatterns)
+	if err != nil {
 		return nil, err
 	}
-	// startPodMonitoring(ctx, m, client)
-	// startNodeMonitoring(ctx, m, client)
-	startEventMonitoring(ctx, m, client)
-	// startClusterOperatorMonitoring(ctx, m, configClient)
+
+	// the sources below record through their Recorder argument but measure the age of
+	// the objects they see on the clock of the monitor
+	monitorClock := m.clock
+	sources := []source{
+		{name: "api", start: func(ctx context.Context, _ Recorder, clusterConfig *rest.Config) error {
+			return startAPIMonitoring(ctx, m, clusterConfig)
+		}},
+		{name: "latency", start: func(ctx context.Context, _ Recorder, clusterConfig *rest.Config) error {
+			return startLatencyMonitoring(ctx, m, clusterConfig, config.LatencyThresholds)
+		}},
+		{name: "health", start: func(ctx context.Context, m Recorder, clusterConfig *rest.Config) error {
+			return startHealthMonitoring(ctx, m, clusterConfig, config.ShardURLs)
+		}},
+		// {name: "pods", start: func(ctx context.Context, m Recorder, _ *rest.Config) error {
+		// 	startPodMonitoring(ctx, m, monitorClock, client, filterToSystemNamespaces)
+		// 	return nil
+		// }},
+		// {name: "nodes", start: func(ctx context.Context, m Recorder, _ *rest.Config) error {
+		// 	startNodeMonitoring(ctx, m, client)
+		// 	return nil
+		// }},
+		{name: "events", start: func(ctx context.Context, m Recorder, _ *rest.Config) error {
+			startEventMonitoring(ctx, m, client)
+			return nil
+		}},
+		{name: "workspace-events", start: func(ctx context.Context, m Recorder, clusterConfig *rest.Config) error {
+			if len(config.WorkspaceEventPrefixes) == 0 {
+				return nil
+			}
+			return startWorkspaceEventMonitoring(ctx, m, clusterConfig, config.WorkspaceEventPrefixes)
+		}},
+		{name: "workspaces", start: func(ctx context.Context, m Recorder, _ *rest.Config) error {
+			startWorkspaceMonitoring(ctx, m, monitorClock, dynamicClient)
+			return nil
+		}},
+		{name: "watches", start: func(ctx context.Context, m Recorder, _ *rest.Config) error {
+			startWatchMonitoring(ctx, m, monitorClock, client, dynamicClient, config.WatchBookmarkTimeout)
+			return nil
+		}},
+		// {name: "clusteroperators", start: func(ctx context.Context, m Recorder, _ *rest.Config) error {
+		// 	startClusterOperatorMonitoring(ctx, m, monitorClock, configClient)
+		// 	return nil
+		// }},
+		{name: "pcluster", start: func(ctx context.Context, m Recorder, _ *rest.Config) error {
+			if len(config.PClusterKubeconfig) == 0 {
+				return nil
+			}
+			return startPClusterMonitoring(ctx, m, monitorClock, config.PClusterKubeconfig)
+		}},
+		{name: "logs", start: func(ctx context.Context, m Recorder, _ *rest.Config) error {
+			startLogFileMonitoring(ctx, m, config.LogFiles, logPatterns)
+			return nil
+		}},
+		{name: "syncer-logs", start: func(ctx context.Context, m Recorder, _ *rest.Config) error {
+			if len(config.PClusterKubeconfig) == 0 {
+				return nil
+			}
+			pclusterClient, err := newPClusterClient(config.PClusterKubeconfig)
+			if err != nil {
+				return err
+			}
+			startSyncerLogMonitoring(ctx, &prefixRecorder{recorder: m, prefix: pclusterLocatorPrefix}, pclusterClient, logPatterns)
+			return nil
+		}},
+	}
+	sources = append(sources, registeredSources()...)
+	if err := validateSourceSelection(sources, config.Monitors); err != nil {
+		return nil, err
+	}
+	for _, s := range sources {
+		if !sourceEnabled(s.name, config.Monitors) {
+			continue
+		}
+		if err := s.start(ctx, m, clusterConfig); err != nil {
+			return nil, fmt.Errorf("could not start the %s monitor: %v", s.name, err)
+		}
+	}
 
 	m.StartSampling(ctx)
 	return m, nil
@@ -63,8 +204,11 @@ func startAPIMonitoring(ctx context.Context, m *Monitor, clusterConfig *rest.Con
 		return err
 	}
 
+	m.metrics.trackAvailability("kube-apiserver")
+	m.metrics.trackAvailability("openshift-apiserver")
+
 	m.AddSampler(
-		StartSampling(ctx, m, time.Second, func(previous bool) (condition *Condition, next bool) {
+		startSampling(ctx, m, m.clock, time.Second, func(previous bool) (condition *Condition, next bool) {
 			_, err := pollingClient.Namespaces().Get("kube-system", metav1.GetOptions{})
 			switch {
 			case err == nil && !previous:
@@ -89,7 +233,7 @@ func startAPIMonitoring(ctx context.Context, m *Monitor, clusterConfig *rest.Con
 	)
 
 	m.AddSampler(
-		StartSampling(ctx, m, time.Second, func(previous bool) (condition *Condition, next bool) {
+		startSampling(ctx, m, m.clock, time.Second, func(previous bool) (condition *Condition, next bool) {
 			_, err := openshiftPollingClient.ImageStreams("openshift-apiserver").Get("missing", metav1.GetOptions{})
 			if !errors.IsUnexpectedServerError(err) && errors.IsNotFound(err) {
 				err = nil
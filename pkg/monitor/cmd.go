@@ -4,16 +4,28 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // Options is used to run a monitoring process against the provided server as
 // a command line interaction.
 type Options struct {
 	Out, ErrOut io.Writer
+
+	// ListenAddr, if set, is the address an HTTP listener serving the monitor
+	// metrics on /metrics and the query API on /events and /conditions is started on.
+	ListenAddr string
+
+	// Config is passed to Start.
+	Config Config
 }
 
 // Run starts monitoring the cluster by invoking Start, periodically printing the
@@ -22,7 +34,7 @@ type Options struct {
 func (opt *Options) Run() error {
 	ctx, cancelFn := context.WithCancel(context.Background())
 	defer cancelFn()
-	abortCh := make(chan os.Signal)
+	abortCh := make(chan os.Signal, 1)
 	go func() {
 		<-abortCh
 		fmt.Fprintf(opt.ErrOut, "Interrupted, terminating\n")
@@ -38,15 +50,21 @@ func (opt *Options) Run() error {
 	}()
 	signal.Notify(abortCh, syscall.SIGINT, syscall.SIGTERM)
 
-	m, err := Start(ctx)
+	m, err := Start(ctx, opt.Config)
 	if err != nil {
 		return err
 	}
 
+	if len(opt.ListenAddr) > 0 {
+		if err := opt.serve(ctx, m); err != nil {
+			return err
+		}
+	}
+
 	go func() {
 		ticker := time.NewTicker(100 * time.Millisecond)
 		defer ticker.Stop()
-		var last time.Time
+		var cursor int64
 		done := false
 		for !done {
 			select {
@@ -54,15 +72,10 @@ func (opt *Options) Run() error {
 			case <-ctx.Done():
 				done = true
 			}
-			events := m.Events(last, time.Time{})
-			if len(events) > 0 {
-				for _, event := range events {
-					if !event.From.Equal(event.To) {
-						continue
-					}
-					fmt.Fprintln(opt.Out, event.String())
-				}
-				last = events[len(events)-1].From
+			var events EventIntervals
+			events, cursor = m.EventsSince(cursor)
+			for _, event := range events {
+				fmt.Fprintln(opt.Out, event.String())
 			}
 		}
 	}()
@@ -79,3 +92,31 @@ func (opt *Options) Run() error {
 
 	return nil
 }
+
+// serve registers the monitor metrics with the default Prometheus registry, which
+// already carries the build info of this binary, and serves them and the query API
+// on ListenAddr until the context is done.
+func (opt *Options) serve(ctx context.Context, m *Monitor) error {
+	if err := prometheus.Register(NewMetricsCollector(m)); err != nil {
+		return fmt.Errorf("could not register monitor metrics: %v", err)
+	}
+	listener, err := net.Listen("tcp", opt.ListenAddr)
+	if err != nil {
+		return fmt.Errorf("could not listen on %s: %v", opt.ListenAddr, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	registerQueryHandlers(ctx, mux, m)
+	server := &http.Server{Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			fmt.Fprintf(opt.ErrOut, "error: monitor listener on %s failed: %v\n", opt.ListenAddr, err)
+		}
+	}()
+	fmt.Fprintf(opt.ErrOut, "Serving monitor metrics and events on %s\n", listener.Addr())
+	return nil
+}
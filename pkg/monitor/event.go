@@ -3,15 +3,42 @@ package monitor
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 )
 
 func startEventMonitoring(ctx context.Context, m Recorder, client kubernetes.Interface) {
+	watchEvents(ctx, m, client, filterToSystemNamespaces, locateEvent)
+}
+
+// startWorkspaceEventMonitoring records the events of every logical cluster through the
+// wildcard logical cluster, keeping only the events of the workspaces whose name starts
+// with one of the prefixes or that are nested in such a workspace.
+func startWorkspaceEventMonitoring(ctx context.Context, m Recorder, clusterConfig *rest.Config, prefixes []string) error {
+	wildcardConfig := rest.CopyConfig(clusterConfig)
+	host, err := wildcardClusterHost(clusterConfig.Host)
+	if err != nil {
+		return err
+	}
+	wildcardConfig.Host = host
+	client, err := kubernetes.NewForConfig(wildcardConfig)
+	if err != nil {
+		return err
+	}
+	watchEvents(ctx, m, client, filterToWorkspaces(prefixes), locateWorkspaceEvent)
+	return nil
+}
+
+func watchEvents(ctx context.Context, m Recorder, client kubernetes.Interface, filter func(runtime.Object) bool, locate func(*corev1.Event) string) {
 	go func() {
 		for {
 			select {
@@ -21,6 +48,10 @@ func startEventMonitoring(ctx context.Context, m Recorder, client kubernetes.Int
 			}
 			events, err := client.CoreV1().Events("").List(metav1.ListOptions{Limit: 1})
 			if err != nil {
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Second):
+				}
 				continue
 			}
 			rv := events.ResourceVersion
@@ -34,7 +65,7 @@ func startEventMonitoring(ctx context.Context, m Recorder, client kubernetes.Int
 					continue
 				}
 				w = watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
-					return in, filterToSystemNamespaces(in.Object)
+					return in, filter(in.Object)
 				})
 				func() {
 					defer w.Stop()
@@ -51,7 +82,7 @@ func startEventMonitoring(ctx context.Context, m Recorder, client kubernetes.Int
 							}
 							condition := Condition{
 								Level:   Info,
-								Locator: locateEvent(obj),
+								Locator: locate(obj),
 								Message: message,
 							}
 							if obj.Type == corev1.EventTypeWarning {
@@ -83,3 +114,52 @@ func startEventMonitoring(ctx context.Context, m Recorder, client kubernetes.Int
 		}
 	}()
 }
+
+// wildcardClusterHost replaces the logical cluster path of a kcp server URL, if any,
+// with the wildcard logical cluster, for example https://kcp:6443/clusters/root becomes
+// https://kcp:6443/clusters/*.
+func wildcardClusterHost(host string) (string, error) {
+	return logicalClusterHost(host, "*")
+}
+
+// logicalClusterHost replaces the logical cluster path of a kcp server URL, if any, with
+// the path of cluster. An empty cluster returns the URL of the server itself.
+func logicalClusterHost(host, cluster string) (string, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return "", fmt.Errorf("could not parse the server URL %q: %v", host, err)
+	}
+	if i := strings.Index(u.Path, "/clusters/"); i >= 0 {
+		u.Path = u.Path[:i]
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	if len(cluster) > 0 {
+		u.Path += "/clusters/" + cluster
+	}
+	return u.String(), nil
+}
+
+// filterToWorkspaces keeps the objects stored in a logical cluster with a workspace
+// whose name starts with one of the prefixes in its path.
+func filterToWorkspaces(prefixes []string) func(runtime.Object) bool {
+	return func(obj runtime.Object) bool {
+		m, ok := obj.(metav1.Object)
+		if !ok {
+			return false
+		}
+		for _, name := range strings.Split(logicalCluster(m), ":") {
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(name, prefix) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// locateWorkspaceEvent prefixes the event locator with the path of the logical cluster
+// the event is stored in, for example "workspace/root:e2e-test-xxxxx ns/default pod/a".
+func locateWorkspaceEvent(event *corev1.Event) string {
+	return fmt.Sprintf("workspace/%s %s", logicalCluster(event), locateEvent(event))
+}
@@ -0,0 +1,59 @@
+package monitor
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_wildcardClusterHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{host: "https://kcp:6443", want: "https://kcp:6443/clusters/%2A"},
+		{host: "https://kcp:6443/clusters/root", want: "https://kcp:6443/clusters/%2A"},
+		{host: "https://kcp:6443/clusters/root:org:ws/", want: "https://kcp:6443/clusters/%2A"},
+		{host: "https://proxy/kcp/clusters/root", want: "https://proxy/kcp/clusters/%2A"},
+	}
+	for _, tt := range tests {
+		got, err := wildcardClusterHost(tt.host)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.host, tt.want, got)
+		}
+	}
+}
+
+func Test_filterToWorkspaces(t *testing.T) {
+	filter := filterToWorkspaces([]string{"e2e-test-"})
+	event := func(cluster string) *corev1.Event {
+		return &corev1.Event{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{logicalClusterAnnotation: cluster}}}
+	}
+	tests := []struct {
+		cluster string
+		want    bool
+	}{
+		{cluster: "root", want: false},
+		{cluster: "root:e2e-test-kcp-syncer-abcde", want: true},
+		{cluster: "root:e2e-test-kcp-syncer-abcde:child", want: true},
+		{cluster: "root:org:other", want: false},
+	}
+	for _, tt := range tests {
+		if got := filter(event(tt.cluster)); got != tt.want {
+			t.Errorf("%s: expected %t, got %t", tt.cluster, tt.want, got)
+		}
+	}
+
+	withClusterName := &corev1.Event{ObjectMeta: metav1.ObjectMeta{ClusterName: "root:e2e-test-a"}}
+	if !filter(withClusterName) {
+		t.Errorf("expected the cluster name to be used without the annotation")
+	}
+	withClusterName.InvolvedObject = corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "a"}
+	if got, want := locateWorkspaceEvent(withClusterName), "workspace/root:e2e-test-a ns/default pod/a"; got != want {
+		t.Errorf("expected locator %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,255 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+var clusterWorkspaceShardsResource = schema.GroupVersionResource{Group: "tenancy.kcp.dev", Version: "v1alpha1", Resource: "clusterworkspaceshards"}
+
+const (
+	// healthInterval is how often the health endpoints are polled.
+	healthInterval = 5 * time.Second
+	// frontProxyLocator is the locator of the server of the cluster configuration,
+	// which is the front proxy in front of the shards.
+	frontProxyLocator = "kcp-front-proxy"
+)
+
+// healthEndpoints are the verbose health endpoints polled, with the level of a
+// failing check.
+var healthEndpoints = []struct {
+	name  string
+	level EventLevel
+}{
+	{name: "readyz", level: Warning},
+	{name: "livez", level: Error},
+}
+
+// healthCheckLine matches the lines of a verbose health endpoint, for example
+// "[+]ping ok" or "[-]etcd failed: reason withheld".
+var healthCheckLine = regexp.MustCompile(`^\[([+-])\](\S+) (.*)$`)
+
+// healthTarget is a server whose health endpoints are polled.
+type healthTarget struct {
+	// locator is the locator of the server, for example "kcp-shard/root".
+	locator string
+	url     string
+}
+
+// startHealthMonitoring polls the verbose readyz and livez endpoints of the front proxy
+// and the shards and records every individual check that starts or stops failing. The
+// shards are the provided URLs, or the ClusterWorkspaceShards of the root workspace.
+func startHealthMonitoring(ctx context.Context, m Recorder, clusterConfig *rest.Config, shardURLs []string) error {
+	frontProxy, err := logicalClusterHost(clusterConfig.Host, "")
+	if err != nil {
+		return err
+	}
+	targets := []healthTarget{{locator: frontProxyLocator, url: frontProxy}}
+	if len(shardURLs) == 0 {
+		shards, err := discoverShards(clusterConfig)
+		if err != nil {
+			m.Record(Condition{
+				Level:   Info,
+				Locator: frontProxyLocator,
+				Message: fmt.Sprintf("could not discover the shards, only the front proxy health is monitored: %v", err),
+			})
+		}
+		targets = append(targets, shards...)
+	}
+	for _, shardURL := range shardURLs {
+		targets = append(targets, healthTarget{locator: fmt.Sprintf("kcp-shard/%s", shardURL), url: shardURL})
+	}
+
+	transport, err := rest.TransportFor(clusterConfig)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Transport: transport, Timeout: healthInterval}
+	for _, target := range targets {
+		for _, endpoint := range healthEndpoints {
+			p := &healthPoller{
+				recorder: m,
+				client:   client,
+				locator:  target.locator + " " + endpoint.name,
+				url:      strings.TrimSuffix(target.url, "/") + "/" + endpoint.name + "?verbose",
+				level:    endpoint.level,
+				failing:  make(map[string]*Condition),
+			}
+			m.AddSampler(p.sample)
+			go p.run(ctx)
+		}
+	}
+	return nil
+}
+
+// discoverShards returns the shards registered in the root workspace.
+func discoverShards(clusterConfig *rest.Config) ([]healthTarget, error) {
+	rootConfig := rest.CopyConfig(clusterConfig)
+	host, err := logicalClusterHost(clusterConfig.Host, "root")
+	if err != nil {
+		return nil, err
+	}
+	rootConfig.Host = host
+	client, err := dynamic.NewForConfig(rootConfig)
+	if err != nil {
+		return nil, err
+	}
+	shards, err := client.Resource(clusterWorkspaceShardsResource).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var targets []healthTarget
+	for _, shard := range shards.Items {
+		baseURL, _, _ := unstructured.NestedString(shard.Object, "spec", "baseURL")
+		if len(baseURL) == 0 {
+			continue
+		}
+		targets = append(targets, healthTarget{locator: fmt.Sprintf("kcp-shard/%s", shard.GetName()), url: baseURL})
+	}
+	return targets, nil
+}
+
+// healthPoller polls one verbose health endpoint and records the checks that flip.
+// The endpoint itself being unreachable is tracked as a check without a name.
+type healthPoller struct {
+	recorder Recorder
+	client   *http.Client
+	locator  string
+	url      string
+	level    EventLevel
+
+	lock sync.Mutex
+	// failing holds the sampled condition of each failing check by name
+	failing map[string]*Condition
+}
+
+func (p *healthPoller) run(ctx context.Context) {
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+	for {
+		p.poll()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *healthPoller) poll() {
+	checks, err := p.get()
+	if err != nil {
+		// the checks are unknown while the endpoint is not responding
+		p.lock.Lock()
+		checks = map[string]string{"": fmt.Sprintf("could not get %s: %v", p.url, err)}
+		for name := range p.failing {
+			if len(name) > 0 {
+				checks[name] = ""
+			}
+		}
+		p.lock.Unlock()
+	}
+	p.update(checks)
+}
+
+// get returns the failing checks of the endpoint with their message.
+func (p *healthPoller) get() (map[string]string, error) {
+	resp, err := p.client.Get(p.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	failing := parseHealthChecks(string(body))
+	if resp.StatusCode != http.StatusOK && len(failing) == 0 {
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return failing, nil
+}
+
+// parseHealthChecks returns the failing checks of a verbose health endpoint response
+// with their message.
+func parseHealthChecks(body string) map[string]string {
+	failing := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		match := healthCheckLine.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match == nil || match[1] != "-" {
+			continue
+		}
+		failing[match[2]] = match[3]
+	}
+	return failing
+}
+
+// update records the checks that started or stopped failing since the last poll. The
+// endpoint not responding is tracked as the check without a name.
+func (p *healthPoller) update(checks map[string]string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	current := make(map[string]*Condition, len(checks))
+	for _, name := range names {
+		if condition, ok := p.failing[name]; ok {
+			current[name] = condition
+			continue
+		}
+		// the sampled condition has a stable message so a failure spans one interval
+		if len(name) == 0 {
+			current[name] = &Condition{Level: p.level, Locator: p.locator, Message: "health endpoint is not responding"}
+			p.recorder.Record(Condition{Level: p.level, Locator: p.locator, Message: checks[name]})
+			continue
+		}
+		locator := p.locator + "/" + name
+		current[name] = &Condition{Level: p.level, Locator: locator, Message: "check is failing"}
+		p.recorder.Record(Condition{Level: p.level, Locator: locator, Message: fmt.Sprintf("check started failing: %s", checks[name])})
+	}
+	recovered := make([]string, 0, len(p.failing))
+	for name := range p.failing {
+		if _, ok := current[name]; !ok {
+			recovered = append(recovered, name)
+		}
+	}
+	sort.Strings(recovered)
+	for _, name := range recovered {
+		condition := p.failing[name]
+		message := "check recovered"
+		if len(name) == 0 {
+			message = "health endpoint is responding"
+		}
+		p.recorder.Record(Condition{Level: Info, Locator: condition.Locator, Message: message})
+	}
+	p.failing = current
+}
+
+func (p *healthPoller) sample(time.Time) []*Condition {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	conditions := make([]*Condition, 0, len(p.failing))
+	for _, condition := range p.failing {
+		conditions = append(conditions, condition)
+	}
+	return conditions
+}
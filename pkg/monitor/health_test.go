@@ -0,0 +1,67 @@
+package monitor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_healthPoller(t *testing.T) {
+	responses := []struct {
+		status int
+		body   string
+	}{
+		{status: http.StatusOK, body: "[+]ping ok\n[+]etcd ok\n[+]informer-sync ok\nreadyz check passed\n"},
+		{status: http.StatusInternalServerError, body: "[+]ping ok\n[-]etcd failed: reason withheld\n[-]informer-sync failed: reason withheld\nreadyz check failed\n"},
+		{status: http.StatusInternalServerError, body: "[+]ping ok\n[-]etcd failed: reason withheld\n[+]informer-sync ok\nreadyz check failed\n"},
+		{status: http.StatusServiceUnavailable, body: "shutting down"},
+		{status: http.StatusOK, body: "[+]ping ok\n[+]etcd ok\n[+]informer-sync ok\nreadyz check passed\n"},
+	}
+	var i int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/readyz" || r.URL.RawQuery != "verbose" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		w.WriteHeader(responses[i].status)
+		fmt.Fprint(w, responses[i].body)
+	}))
+	defer server.Close()
+
+	m := NewMonitor()
+	p := &healthPoller{
+		recorder: m,
+		client:   server.Client(),
+		locator:  "kcp-shard/root readyz",
+		url:      server.URL + "/readyz?verbose",
+		level:    Warning,
+		failing:  make(map[string]*Condition),
+	}
+	var sampled [][]*Condition
+	for i = range responses {
+		p.poll()
+		sampled = append(sampled, p.sample(time.Now()))
+	}
+
+	var events []string
+	for _, event := range m.Events(time.Time{}, time.Time{}) {
+		events = append(events, fmt.Sprintf("%s %s: %s", event.Level, event.Locator, event.Message))
+	}
+	want := []string{
+		"Warning kcp-shard/root readyz/etcd: check started failing: failed: reason withheld",
+		"Warning kcp-shard/root readyz/informer-sync: check started failing: failed: reason withheld",
+		"Info kcp-shard/root readyz/informer-sync: check recovered",
+		"Warning kcp-shard/root readyz: could not get " + server.URL + "/readyz?verbose: unexpected status 503: shutting down",
+		"Info kcp-shard/root readyz: health endpoint is responding",
+		"Info kcp-shard/root readyz/etcd: check recovered",
+	}
+	if fmt.Sprint(events) != fmt.Sprint(want) {
+		t.Errorf("unexpected events:\n%s", events)
+	}
+	for i, n := range []int{0, 2, 1, 2, 0} {
+		if len(sampled[i]) != n {
+			t.Errorf("%d: expected %d sampled conditions, got %v", i, n, sampled[i])
+		}
+	}
+}
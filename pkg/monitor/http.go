@@ -0,0 +1,161 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// streamInterval is how often the event stream checks for new events.
+const streamInterval = 100 * time.Millisecond
+
+// intervalJSON is the JSON form of an EventInterval served by the query API.
+type intervalJSON struct {
+	Level   string    `json:"level"`
+	Locator string    `json:"locator"`
+	Message string    `json:"message"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+}
+
+func newIntervalJSON(interval *EventInterval) intervalJSON {
+	return intervalJSON{
+		Level:   interval.Level.String(),
+		Locator: interval.Locator,
+		Message: interval.Message,
+		From:    interval.From,
+		To:      interval.To,
+	}
+}
+
+// intervalQuery selects the intervals between from and to, at or above level and
+// with a locator matching locator.
+type intervalQuery struct {
+	from, to time.Time
+	level    EventLevel
+	locator  *regexp.Regexp
+}
+
+// parseIntervalQuery reads the from and to RFC 3339 times, the minimum level and the
+// locator regular expression from the query parameters, all of them are optional.
+func parseIntervalQuery(r *http.Request) (*intervalQuery, error) {
+	values := r.URL.Query()
+	q := &intervalQuery{}
+	var err error
+	if v := values.Get("from"); len(v) > 0 {
+		if q.from, err = time.Parse(time.RFC3339Nano, v); err != nil {
+			return nil, fmt.Errorf("from must be an RFC 3339 time: %v", err)
+		}
+	}
+	if v := values.Get("to"); len(v) > 0 {
+		if q.to, err = time.Parse(time.RFC3339Nano, v); err != nil {
+			return nil, fmt.Errorf("to must be an RFC 3339 time: %v", err)
+		}
+	}
+	if v := values.Get("level"); len(v) > 0 {
+		if q.level, err = ParseEventLevel(v); err != nil {
+			return nil, err
+		}
+	}
+	if v := values.Get("locator"); len(v) > 0 {
+		if q.locator, err = regexp.Compile(v); err != nil {
+			return nil, fmt.Errorf("locator must be a regular expression: %v", err)
+		}
+	}
+	return q, nil
+}
+
+func (q *intervalQuery) filter(intervals EventIntervals) []intervalJSON {
+	result := make([]intervalJSON, 0, len(intervals))
+	for _, interval := range intervals {
+		if interval.Level < q.level {
+			continue
+		}
+		if q.locator != nil && !q.locator.MatchString(interval.Locator) {
+			continue
+		}
+		result = append(result, newIntervalJSON(interval))
+	}
+	return result
+}
+
+// registerQueryHandlers serves the monitor over HTTP on mux:
+//
+//	/events?from=&to=&level=&locator=      the events and sampled conditions, see Monitor.Events
+//	/conditions?from=&to=&level=&locator=  the sampled conditions, see Monitor.Conditions
+//	/events/stream?level=&locator=         the events recorded from now on as server-sent events
+//
+// from and to are RFC 3339 times, level is the minimum level and locator a regular
+// expression matched against the locators.
+func registerQueryHandlers(ctx context.Context, mux *http.ServeMux, m *Monitor) {
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		serveIntervals(w, r, m.Events)
+	})
+	mux.HandleFunc("/conditions", func(w http.ResponseWriter, r *http.Request) {
+		serveIntervals(w, r, m.Conditions)
+	})
+	mux.HandleFunc("/events/stream", func(w http.ResponseWriter, r *http.Request) {
+		streamEvents(ctx, w, r, m)
+	})
+}
+
+func serveIntervals(w http.ResponseWriter, r *http.Request, intervals func(from, to time.Time) EventIntervals) {
+	q, err := parseIntervalQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(q.filter(intervals(q.from, q.to)))
+}
+
+// streamEvents sends the events recorded after the request as server-sent events, one
+// JSON interval per event, until the client goes away or ctx is done.
+func streamEvents(ctx context.Context, w http.ResponseWriter, r *http.Request, m *Monitor) {
+	q, err := parseIntervalQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	// events recorded once the client has the response headers are streamed
+	_, cursor := m.EventsSince(0)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(streamInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+		var events EventIntervals
+		events, cursor = m.EventsSince(cursor)
+		filtered := q.filter(events)
+		for _, event := range filtered {
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+		}
+		if len(filtered) > 0 {
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMonitor_QueryAPI(t *testing.T) {
+	m := NewMonitor()
+	m.Record(
+		Condition{Level: Info, Locator: "workspace/root:e2e-test-a", Message: "created"},
+		Condition{Level: Error, Locator: "kube-apiserver", Message: "Kube API started failing"},
+	)
+	m.AddSampler(func(time.Time) []*Condition {
+		return []*Condition{{Level: Warning, Locator: "watch/namespaces", Message: "watch is disrupted"}}
+	})
+	m.sample()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mux := http.NewServeMux()
+	registerQueryHandlers(ctx, mux, m)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		path     string
+		status   int
+		messages []string
+	}{
+		{path: "/events", status: http.StatusOK, messages: []string{"Kube API started failing", "created", "watch is disrupted"}},
+		{path: "/events?level=Warning", status: http.StatusOK, messages: []string{"Kube API started failing", "watch is disrupted"}},
+		{path: "/events?locator=^workspace/", status: http.StatusOK, messages: []string{"created"}},
+		{path: "/events?from=" + time.Now().Add(time.Hour).Format(time.RFC3339), status: http.StatusOK, messages: []string{}},
+		{path: "/conditions", status: http.StatusOK, messages: []string{"watch is disrupted"}},
+		{path: "/events?level=Critical", status: http.StatusBadRequest},
+		{path: "/events?from=yesterday", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(server.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, resp.StatusCode)
+		}
+		if tt.status == http.StatusOK {
+			var intervals []intervalJSON
+			if err := json.NewDecoder(resp.Body).Decode(&intervals); err != nil {
+				t.Errorf("%s: %v", tt.path, err)
+			}
+			messages := []string{}
+			for _, interval := range intervals {
+				messages = append(messages, interval.Message)
+			}
+			if strings.Join(messages, ",") != strings.Join(tt.messages, ",") {
+				t.Errorf("%s: expected %q, got %q", tt.path, tt.messages, messages)
+			}
+		}
+		resp.Body.Close()
+	}
+
+	resp, err := http.Get(server.URL + "/events/stream?level=Warning")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	m.Record(
+		Condition{Level: Info, Locator: "workspace/root:e2e-test-a", Message: "deleted"},
+		Condition{Level: Warning, Locator: "watch/namespaces", Message: "watch expired"},
+	)
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event intervalJSON
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+		t.Fatalf("unexpected stream line %q: %v", line, err)
+	}
+	if event.Message != "watch expired" || event.Level != "Warning" {
+		t.Errorf("unexpected streamed event: %#v", event)
+	}
+}
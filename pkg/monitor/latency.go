@@ -0,0 +1,222 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// LatencyThresholds configures when the request latency sampler reports a request
+// as slow. Thresholds are compared against the p95 latency of the rolling window,
+// a zero value selects the default.
+type LatencyThresholds struct {
+	// Warning is the p95 latency above which a Warning condition is reported.
+	Warning time.Duration
+	// Error is the p95 latency above which an Error condition is reported.
+	Error time.Duration
+	// Window is the number of most recent requests the percentiles are computed over.
+	Window int
+}
+
+func (t LatencyThresholds) withDefaults() LatencyThresholds {
+	if t.Warning == 0 {
+		t.Warning = time.Second
+	}
+	if t.Error == 0 {
+		t.Error = 3 * time.Second
+	}
+	if t.Window == 0 {
+		t.Window = 60
+	}
+	return t
+}
+
+// latencyRequest is a read against kcp whose latency is sampled.
+type latencyRequest struct {
+	name string
+	path string
+}
+
+var latencyRequests = []latencyRequest{
+	{name: "workspaces-list", path: "/apis/tenancy.kcp.dev/v1beta1/workspaces"},
+	{name: "namespace-get", path: "/api/v1/namespaces/default"},
+	{name: "discovery", path: "/apis"},
+}
+
+// latencyPercentiles are the rolling latency percentiles of a request.
+type latencyPercentiles struct {
+	p50, p95, p99 time.Duration
+}
+
+func (p latencyPercentiles) String() string {
+	return fmt.Sprintf("p50 %s, p95 %s, p99 %s", p.p50, p.p95, p.p99)
+}
+
+// latencyWindow keeps the latencies of the most recent requests in a ring.
+type latencyWindow struct {
+	durations []time.Duration
+	next      int
+	full      bool
+}
+
+func newLatencyWindow(size int) *latencyWindow {
+	return &latencyWindow{durations: make([]time.Duration, size)}
+}
+
+func (w *latencyWindow) add(d time.Duration) {
+	w.durations[w.next] = d
+	w.next = (w.next + 1) % len(w.durations)
+	if w.next == 0 {
+		w.full = true
+	}
+}
+
+func (w *latencyWindow) percentiles() latencyPercentiles {
+	n := w.next
+	if w.full {
+		n = len(w.durations)
+	}
+	if n == 0 {
+		return latencyPercentiles{}
+	}
+	sorted := make([]time.Duration, n)
+	copy(sorted, w.durations[:n])
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	at := func(q float64) time.Duration {
+		return sorted[int(q*float64(n-1)+0.5)]
+	}
+	return latencyPercentiles{p50: at(0.50), p95: at(0.95), p99: at(0.99)}
+}
+
+// latencySampler tracks the latency of one request and the level it is reported at.
+type latencySampler struct {
+	request    latencyRequest
+	thresholds LatencyThresholds
+
+	lock   sync.Mutex
+	window *latencyWindow
+	level  EventLevel
+}
+
+func (s *latencySampler) locator() string {
+	return fmt.Sprintf("kcp-api request/%s", s.request.name)
+}
+
+// observe adds a latency to the window and returns a condition if the level the
+// request is reported at changed.
+func (s *latencySampler) observe(d time.Duration) (*Condition, latencyPercentiles) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.window.add(d)
+	p := s.window.percentiles()
+
+	level := Info
+	switch {
+	case p.p95 > s.thresholds.Error:
+		level = Error
+	case p.p95 > s.thresholds.Warning:
+		level = Warning
+	}
+	if level == s.level {
+		return nil, p
+	}
+	previous := s.level
+	s.level = level
+	switch {
+	case level == Info:
+		return &Condition{
+			Level:   Info,
+			Locator: s.locator(),
+			Message: fmt.Sprintf("request latency recovered below %s (%s)", s.thresholds.Warning, p),
+		}, p
+	case level > previous:
+		return &Condition{
+			Level:   level,
+			Locator: s.locator(),
+			Message: fmt.Sprintf("request latency exceeded %s (%s)", s.threshold(level), p),
+		}, p
+	default:
+		return &Condition{
+			Level:   level,
+			Locator: s.locator(),
+			Message: fmt.Sprintf("request latency dropped below %s (%s)", s.thresholds.Error, p),
+		}, p
+	}
+}
+
+func (s *latencySampler) threshold(level EventLevel) time.Duration {
+	if level == Error {
+		return s.thresholds.Error
+	}
+	return s.thresholds.Warning
+}
+
+// ConditionWhenSlow returns a SamplerFunc reporting a condition for as long as
+// the request latency is above one of the thresholds.
+func (s *latencySampler) ConditionWhenSlow() SamplerFunc {
+	return func(_ time.Time) []*Condition {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		if s.level == Info {
+			return nil
+		}
+		return []*Condition{{
+			Level:   s.level,
+			Locator: s.locator(),
+			Message: fmt.Sprintf("request latency is above %s", s.threshold(s.level)),
+		}}
+	}
+}
+
+// startLatencyMonitoring measures the latency of a fixed set of kcp reads every
+// second and reports when the rolling p95 crosses the configured thresholds. Unlike
+// the API availability samplers the requests are allowed to take much longer than
+// the thresholds, so slow but successful responses are measured.
+func startLatencyMonitoring(ctx context.Context, m *Monitor, clusterConfig *rest.Config, thresholds LatencyThresholds) error {
+	thresholds = thresholds.withDefaults()
+	pollingConfig := *clusterConfig
+	pollingConfig.Timeout = 4 * thresholds.Error
+	client, err := kubernetes.NewForConfig(&pollingConfig)
+	if err != nil {
+		return err
+	}
+
+	for _, request := range latencyRequests {
+		s := &latencySampler{
+			request:    request,
+			thresholds: thresholds,
+			window:     newLatencyWindow(thresholds.Window),
+		}
+		m.AddSampler(s.ConditionWhenSlow())
+		go func() {
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					return
+				}
+				start := time.Now()
+				err := client.CoreV1().RESTClient().Get().AbsPath(s.request.path).Do().Error()
+				latency := time.Since(start)
+				if err != nil && !errors.IsNotFound(err) {
+					// failures are reported by the availability samplers
+					continue
+				}
+				condition, p := s.observe(latency)
+				m.metrics.recordLatency(s.request.name, p)
+				if condition != nil {
+					m.Record(*condition)
+				}
+			}
+		}()
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_latencyWindow(t *testing.T) {
+	w := newLatencyWindow(100)
+	if p := w.percentiles(); p != (latencyPercentiles{}) {
+		t.Fatalf("unexpected percentiles of an empty window: %v", p)
+	}
+	for i := 1; i <= 150; i++ {
+		w.add(time.Duration(i) * time.Millisecond)
+	}
+	p := w.percentiles()
+	if p.p50 != 101*time.Millisecond || p.p95 != 145*time.Millisecond || p.p99 != 149*time.Millisecond {
+		t.Errorf("unexpected percentiles: %v", p)
+	}
+}
+
+func Test_latencySampler(t *testing.T) {
+	s := &latencySampler{
+		request:    latencyRequest{name: "discovery"},
+		thresholds: LatencyThresholds{Warning: time.Second, Error: 3 * time.Second},
+		window:     newLatencyWindow(2),
+	}
+	steps := []struct {
+		latency time.Duration
+		want    *EventLevel
+	}{
+		{latency: 100 * time.Millisecond},
+		{latency: 2 * time.Second, want: levelPtr(Warning)},
+		{latency: 4 * time.Second, want: levelPtr(Error)},
+		{latency: 2 * time.Second},
+		{latency: 2 * time.Second, want: levelPtr(Warning)},
+		{latency: 100 * time.Millisecond},
+		{latency: 100 * time.Millisecond, want: levelPtr(Info)},
+	}
+	for i, step := range steps {
+		condition, _ := s.observe(step.latency)
+		switch {
+		case step.want == nil && condition != nil:
+			t.Errorf("%d: unexpected condition %#v", i, condition)
+		case step.want != nil && condition == nil:
+			t.Errorf("%d: expected a %s condition", i, *step.want)
+		case step.want != nil && condition.Level != *step.want:
+			t.Errorf("%d: expected a %s condition, got %#v", i, *step.want, condition)
+		}
+	}
+	if conditions := s.ConditionWhenSlow()(time.Now()); len(conditions) != 0 {
+		t.Errorf("unexpected conditions after recovery: %v", conditions)
+	}
+}
+
+func levelPtr(l EventLevel) *EventLevel {
+	return &l
+}
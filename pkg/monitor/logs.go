@@ -0,0 +1,316 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// defaultLogPatterns match the lines of the kcp server and syncer logs worth putting on
+// the timeline: panics, klog errors and leader election changes.
+var defaultLogPatterns = []string{
+	`Error=^panic: `,
+	`Error=^fatal error: `,
+	`Warning=^E\d{4} `,
+	`Warning=leaderelection.*(failed to renew lease|lost lease|stopped leading)`,
+	`Info=leaderelection.*successfully acquired lease`,
+}
+
+// maxLogMessage is the longest log line recorded, longer lines are truncated.
+const maxLogMessage = 1024
+
+// logPattern records the log lines matching pattern with level.
+type logPattern struct {
+	level   EventLevel
+	pattern *regexp.Regexp
+}
+
+// parseLogPatterns compiles patterns of the form LEVEL=REGEXP, for example
+// "Warning=^E\d{4} ". The default patterns are returned if none are provided.
+func parseLogPatterns(patterns []string) ([]logPattern, error) {
+	if len(patterns) == 0 {
+		patterns = defaultLogPatterns
+	}
+	parsed := make([]logPattern, 0, len(patterns))
+	for _, p := range patterns {
+		parts := strings.SplitN(p, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("log pattern %q must be of the form LEVEL=REGEXP", p)
+		}
+		level, err := ParseEventLevel(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("log pattern %q: %v", p, err)
+		}
+		re, err := regexp.Compile(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("log pattern %q: %v", p, err)
+		}
+		parsed = append(parsed, logPattern{level: level, pattern: re})
+	}
+	return parsed, nil
+}
+
+// matchLogLine returns the condition for a log line, or nil if no pattern matches it.
+// The first matching pattern sets the level.
+func matchLogLine(patterns []logPattern, locator, line string) *Condition {
+	line = strings.TrimRight(line, "\r\n")
+	for _, p := range patterns {
+		if !p.pattern.MatchString(line) {
+			continue
+		}
+		if len(line) > maxLogMessage {
+			// cut at the start of a rune so the message stays valid UTF-8
+			n := maxLogMessage
+			for n > 0 && !utf8.RuneStart(line[n]) {
+				n--
+			}
+			line = line[:n] + "..."
+		}
+		return &Condition{
+			Level:   p.level,
+			Locator: locator,
+			Message: line,
+		}
+	}
+	return nil
+}
+
+// recordLogLines records a condition for every line read from r that matches a pattern
+// until r is exhausted.
+func recordLogLines(m Recorder, patterns []logPattern, locator string, r io.Reader) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			if condition := matchLogLine(patterns, locator, line); condition != nil {
+				m.Record(*condition)
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// startLogFileMonitoring follows the local log files, for a kcp server running as a
+// local process, starting at their current end.
+func startLogFileMonitoring(ctx context.Context, m Recorder, paths []string, patterns []logPattern) {
+	for _, path := range paths {
+		go func(path string) {
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			tailLogFile(ctx, m, path, patterns, ticker.C)
+		}(path)
+	}
+}
+
+// tailLogFile reads the lines appended to the file at path, and checks whether the file
+// was replaced, every time tick fires.
+func tailLogFile(ctx context.Context, m Recorder, path string, patterns []logPattern, tick <-chan time.Time) {
+	locator := fmt.Sprintf("log/%s", filepath.Base(path))
+	var f *os.File
+	var reader *bufio.Reader
+	var partial string
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+	fromStart := false
+	for {
+		if f == nil {
+			var err error
+			if f, err = os.Open(path); err == nil {
+				if !fromStart {
+					f.Seek(0, io.SeekEnd)
+				}
+				reader = bufio.NewReader(f)
+				partial = ""
+			}
+			// a file that appears or is replaced later is read from its start
+			fromStart = true
+		}
+		for f != nil {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				partial += line
+				break
+			}
+			if condition := matchLogLine(patterns, locator, partial+line); condition != nil {
+				m.Record(*condition)
+			}
+			partial = ""
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		}
+		if f != nil && logFileReplaced(f, path) {
+			f.Close()
+			f = nil
+		}
+	}
+}
+
+// logFileReplaced returns true if the file at path was rotated or truncated since f
+// was opened.
+func logFileReplaced(f *os.File, path string) bool {
+	opened, err := f.Stat()
+	if err != nil {
+		return true
+	}
+	current, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if !os.SameFile(opened, current) {
+		return true
+	}
+	offset, err := f.Seek(0, io.SeekCurrent)
+	return err == nil && current.Size() < offset
+}
+
+// followedContainers tracks the restart of the containers whose logs are followed, so
+// a container is followed again when it restarts. The pods that are gone are forgotten.
+type followedContainers struct {
+	lock sync.Mutex
+	// restarts are the restart counts followed by pod namespace and name, and container
+	restarts map[string]map[string]int32
+}
+
+func newFollowedContainers() *followedContainers {
+	return &followedContainers{restarts: make(map[string]map[string]int32)}
+}
+
+// follow returns true if the restart of the container of pod is not followed yet, and
+// tracks it as followed.
+func (f *followedContainers) follow(pod *corev1.Pod, container string, restartCount int32) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	key := pod.Namespace + "/" + pod.Name
+	containers, ok := f.restarts[key]
+	if !ok {
+		containers = make(map[string]int32)
+		f.restarts[key] = containers
+	}
+	if followed, ok := containers[container]; ok && followed == restartCount {
+		return false
+	}
+	containers[container] = restartCount
+	return true
+}
+
+// forget stops tracking the containers of pod.
+func (f *followedContainers) forget(pod *corev1.Pod) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.restarts, pod.Namespace+"/"+pod.Name)
+}
+
+// startSyncerLogMonitoring follows the logs of the containers of the pods in the syncer
+// namespaces, like kubectl logs -f, from the time the monitor is started.
+func startSyncerLogMonitoring(ctx context.Context, m Recorder, client kubernetes.Interface, patterns []logPattern) {
+	startTime := metav1.Now()
+	following := newFollowedContainers()
+	follow := func(pod *corev1.Pod) {
+		if !strings.HasPrefix(pod.Namespace, syncerNamespacePrefix) {
+			return
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Running == nil {
+				continue
+			}
+			if !following.follow(pod, status.Name, status.RestartCount) {
+				continue
+			}
+			since := startTime
+			if started := status.State.Running.StartedAt; started.After(since.Time) {
+				since = started
+			}
+			locator := locatePodContainer(pod, status.Name)
+			go func(namespace, name, container string) {
+				stream, err := client.CoreV1().Pods(namespace).GetLogs(name, &corev1.PodLogOptions{
+					Container: container,
+					Follow:    true,
+					SinceTime: &since,
+				}).Stream()
+				if err != nil {
+					m.Record(Condition{
+						Level:   Info,
+						Locator: locator,
+						Message: fmt.Sprintf("could not follow the container logs: %v", err),
+					})
+					return
+				}
+				done := make(chan struct{})
+				defer close(done)
+				go func() {
+					select {
+					case <-ctx.Done():
+					case <-done:
+					}
+					stream.Close()
+				}()
+				recordLogLines(m, patterns, locator, stream)
+			}(pod.Namespace, pod.Name, status.Name)
+		}
+	}
+
+	podInformer := cache.NewSharedIndexInformer(
+		NewErrorRecordingListWatcher(m, &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.FieldSelector = fields.OneTermEqualSelector("status.phase", string(corev1.PodRunning)).String()
+				return client.CoreV1().Pods("").List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.FieldSelector = fields.OneTermEqualSelector("status.phase", string(corev1.PodRunning)).String()
+				return client.CoreV1().Pods("").Watch(options)
+			},
+		}),
+		&corev1.Pod{},
+		time.Hour,
+		nil,
+	)
+	podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if pod, ok := obj.(*corev1.Pod); ok {
+				follow(pod)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			if pod, ok := obj.(*corev1.Pod); ok {
+				follow(pod)
+			}
+		},
+		// a pod is deleted from the informer when it is gone or no longer running
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if pod, ok := obj.(*corev1.Pod); ok {
+				following.forget(pod)
+			}
+		},
+	})
+	go podInformer.Run(ctx.Done())
+}
@@ -0,0 +1,150 @@
+package monitor
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_matchLogLine(t *testing.T) {
+	patterns, err := parseLogPatterns(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		line string
+		want *EventLevel
+	}{
+		{line: "I0412 10:00:00.000000       1 controller.go:42] Starting\n"},
+		{line: "E0412 10:00:00.000000       1 reflector.go:138] failed to list\n", want: levelPtr(Warning)},
+		{line: "panic: runtime error: invalid memory address or nil pointer dereference\n", want: levelPtr(Error)},
+		{line: "I0412 10:00:00.000000       1 leaderelection.go:258] successfully acquired lease kcp-syncer/lock\n", want: levelPtr(Info)},
+		{line: "E0412 10:00:00.000000       1 leaderelection.go:367] Failed to update lock: lost lease\n", want: levelPtr(Warning)},
+	}
+	for _, tt := range tests {
+		condition := matchLogLine(patterns, "log/kcp.log", tt.line)
+		switch {
+		case tt.want == nil && condition != nil:
+			t.Errorf("%q: unexpected condition %#v", tt.line, condition)
+		case tt.want != nil && condition == nil:
+			t.Errorf("%q: expected a %s condition", tt.line, *tt.want)
+		case tt.want != nil && condition.Level != *tt.want:
+			t.Errorf("%q: expected a %s condition, got %#v", tt.line, *tt.want, condition)
+		}
+	}
+
+	// a long line is truncated at the start of a rune
+	long := "E0412 " + strings.Repeat("é", maxLogMessage)
+	condition := matchLogLine(patterns, "log/kcp.log", long)
+	if condition == nil || !utf8.ValidString(condition.Message) || len(condition.Message) > maxLogMessage+len("...") || !strings.HasSuffix(condition.Message, "é...") {
+		t.Errorf("unexpected truncated message %#v", condition)
+	}
+
+	for _, invalid := range [][]string{{"^panic"}, {"Critical=^panic"}, {"Error=("}} {
+		if _, err := parseLogPatterns(invalid); err == nil {
+			t.Errorf("expected an error for %v", invalid)
+		}
+	}
+}
+
+func Test_tailLogFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "monitor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "kcp.log")
+	if err := ioutil.WriteFile(path, []byte("panic: before the monitor started\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	patterns, err := parseLogPatterns(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := NewMonitor()
+	done := make(chan struct{})
+	tick := make(chan time.Time)
+	go func() {
+		defer close(done)
+		tailLogFile(ctx, m, path, patterns, tick)
+	}()
+	// a tick is received once the file was read up to then, the next one once the
+	// lines written in between were read
+	next := func() {
+		tick <- time.Now()
+		tick <- time.Now()
+	}
+	next()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("I0412 10:00:00.000000 1 ok\nE0412 10:00:00.000000 1 split")
+	next()
+	f.WriteString(" line\n")
+	f.Close()
+	next()
+
+	// a truncated file is read again from its start
+	if err := ioutil.WriteFile(path, []byte("panic: after truncation\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	next()
+	cancel()
+	<-done
+
+	var messages []string
+	for _, event := range m.Events(time.Time{}, time.Time{}) {
+		if event.Locator != "log/kcp.log" {
+			t.Errorf("unexpected locator: %s", event.Locator)
+		}
+		messages = append(messages, event.Message)
+	}
+	if len(messages) != 2 || messages[0] != "E0412 10:00:00.000000 1 split line" || messages[1] != "panic: after truncation" {
+		t.Errorf("unexpected events: %q", messages)
+	}
+}
+
+func Test_followedContainers(t *testing.T) {
+	following := newFollowedContainers()
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "kcp-syncer-a", Name: "syncer-0"}}
+	for i, tt := range []struct {
+		container string
+		restarts  int32
+		want      bool
+	}{
+		{container: "syncer", want: true},
+		{container: "syncer"},
+		{container: "sidecar", want: true},
+		{container: "syncer", restarts: 1, want: true},
+		{container: "syncer", restarts: 1},
+	} {
+		if got := following.follow(pod, tt.container, tt.restarts); got != tt.want {
+			t.Errorf("%d: expected follow %t for %s restarted %d times", i, tt.want, tt.container, tt.restarts)
+		}
+	}
+	if len(following.restarts) != 1 || len(following.restarts["kcp-syncer-a/syncer-0"]) != 2 {
+		t.Errorf("expected only the last restart of each container to be tracked, got %v", following.restarts)
+	}
+
+	// a pod that is gone is forgotten, one recreated with the same name is followed
+	following.forget(pod)
+	if len(following.restarts) != 0 {
+		t.Errorf("expected the pod to be forgotten, got %v", following.restarts)
+	}
+	if !following.follow(pod, "syncer", 0) {
+		t.Error("expected the recreated pod to be followed")
+	}
+}
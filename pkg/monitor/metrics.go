@@ -0,0 +1,161 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	availableDesc = prometheus.NewDesc(
+		"kcp_monitor_available",
+		"Whether the monitored locator is currently available (1) or is reporting error level conditions (0).",
+		[]string{"locator"}, nil,
+	)
+	outageDesc = prometheus.NewDesc(
+		"kcp_monitor_outage_duration_seconds",
+		"The length in seconds of the current outage for the monitored locator, 0 when the locator is available.",
+		[]string{"locator"}, nil,
+	)
+	eventsDesc = prometheus.NewDesc(
+		"kcp_monitor_events_total",
+		"The number of events recorded by the monitor, by level and locator.",
+		[]string{"level", "locator"}, nil,
+	)
+	latencyDesc = prometheus.NewDesc(
+		"kcp_monitor_request_latency_seconds",
+		"The rolling latency percentiles of the requests sampled by the monitor.",
+		[]string{"request", "quantile"}, nil,
+	)
+)
+
+type eventKey struct {
+	level   EventLevel
+	locator string
+}
+
+// monitorMetrics tracks the monitor state that is exposed as Prometheus metrics.
+// Event counts are accumulated as events are recorded so they stay monotonic
+// regardless of how long the monitor retains the events themselves.
+type monitorMetrics struct {
+	lock sync.Mutex
+	// events is the number of recorded events per level and locator
+	events map[eventKey]float64
+	// outages is the start of the current outage per locator, or the zero
+	// time if a locator that previously failed is available again
+	outages map[string]time.Time
+	// latencies are the latest rolling latency percentiles per sampled request
+	latencies map[string]latencyPercentiles
+}
+
+func newMonitorMetrics() *monitorMetrics {
+	return &monitorMetrics{
+		events:    make(map[eventKey]float64),
+		outages:   make(map[string]time.Time),
+		latencies: make(map[string]latencyPercentiles),
+	}
+}
+
+func (m *monitorMetrics) recordEvents(conditions []Condition) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, condition := range conditions {
+		m.events[eventKey{level: condition.Level, locator: condition.Locator}]++
+	}
+}
+
+// trackAvailability reports the locator as available until a sample says otherwise,
+// so that scrapers see locators that have never failed.
+func (m *monitorMetrics) trackAvailability(locator string) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.outages[locator]; !ok {
+		m.outages[locator] = time.Time{}
+	}
+}
+
+// recordSample marks every locator with an error level condition in the sample as
+// unavailable and every previously failing locator that is absent as available.
+func (m *monitorMetrics) recordSample(at time.Time, conditions []*Condition) {
+	if m == nil {
+		return
+	}
+	failing := make(map[string]struct{})
+	for _, condition := range conditions {
+		if condition.Level == Error {
+			failing[condition.Locator] = struct{}{}
+		}
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for locator := range failing {
+		if start, ok := m.outages[locator]; !ok || start.IsZero() {
+			m.outages[locator] = at
+		}
+	}
+	for locator := range m.outages {
+		if _, ok := failing[locator]; !ok {
+			m.outages[locator] = time.Time{}
+		}
+	}
+}
+
+func (m *monitorMetrics) recordLatency(request string, p latencyPercentiles) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.latencies[request] = p
+}
+
+// metricsCollector exposes the metrics of a Monitor to a Prometheus registry.
+type metricsCollector struct {
+	metrics *monitorMetrics
+}
+
+// NewMetricsCollector returns a Prometheus collector reporting availability per
+// locator, the length of current outages, the number of recorded events and the
+// sampled request latencies.
+func NewMetricsCollector(m *Monitor) prometheus.Collector {
+	return &metricsCollector{metrics: m.metrics}
+}
+
+func (c *metricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- availableDesc
+	ch <- outageDesc
+	ch <- eventsDesc
+	ch <- latencyDesc
+}
+
+func (c *metricsCollector) Collect(ch chan<- prometheus.Metric) {
+	if c.metrics == nil {
+		return
+	}
+	now := time.Now().UTC()
+	c.metrics.lock.Lock()
+	defer c.metrics.lock.Unlock()
+	for locator, start := range c.metrics.outages {
+		available, outage := 1.0, 0.0
+		if !start.IsZero() {
+			available, outage = 0, now.Sub(start).Seconds()
+		}
+		ch <- prometheus.MustNewConstMetric(availableDesc, prometheus.GaugeValue, available, locator)
+		ch <- prometheus.MustNewConstMetric(outageDesc, prometheus.GaugeValue, outage, locator)
+	}
+	for key, count := range c.metrics.events {
+		ch <- prometheus.MustNewConstMetric(eventsDesc, prometheus.CounterValue, count, key.level.String(), key.locator)
+	}
+	for request, p := range c.metrics.latencies {
+		ch <- prometheus.MustNewConstMetric(latencyDesc, prometheus.GaugeValue, p.p50.Seconds(), request, "0.5")
+		ch <- prometheus.MustNewConstMetric(latencyDesc, prometheus.GaugeValue, p.p95.Seconds(), request, "0.95")
+		ch <- prometheus.MustNewConstMetric(latencyDesc, prometheus.GaugeValue, p.p99.Seconds(), request, "0.99")
+	}
+}
@@ -0,0 +1,56 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMonitor_Metrics(t *testing.T) {
+	m := NewMonitor()
+	m.metrics.trackAvailability("kube-apiserver")
+	m.Record(
+		Condition{Level: Warning, Locator: "ns/a pod/b", Message: "BackOff"},
+		Condition{Level: Warning, Locator: "ns/a pod/b", Message: "BackOff"},
+	)
+	m.metrics.recordSample(time.Now().Add(-time.Minute), []*Condition{{Level: Error, Locator: "kube-apiserver"}})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewMetricsCollector(m))
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string]float64)
+	for _, family := range families {
+		for _, metric := range family.Metric {
+			switch {
+			case metric.Gauge != nil:
+				values[family.GetName()] = metric.Gauge.GetValue()
+			case metric.Counter != nil:
+				values[family.GetName()] = metric.Counter.GetValue()
+			}
+		}
+	}
+	if values["kcp_monitor_available"] != 0 {
+		t.Errorf("expected kube-apiserver to be unavailable: %v", values)
+	}
+	if values["kcp_monitor_outage_duration_seconds"] < 60 {
+		t.Errorf("expected an outage of at least a minute: %v", values)
+	}
+	if values["kcp_monitor_events_total"] != 2 {
+		t.Errorf("expected two events: %v", values)
+	}
+
+	m.metrics.recordSample(time.Now(), nil)
+	families, err = registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() == "kcp_monitor_available" && family.Metric[0].Gauge.GetValue() != 1 {
+			t.Errorf("expected kube-apiserver to be available again")
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"sort"
 	"sync"
 	"time"
+
+	"k8s.io/apimachinery/pkg/util/clock"
 )
 
 // Monitor records events that have occurred in memory and can also periodically
@@ -13,17 +15,57 @@ import (
 type Monitor struct {
 	interval time.Duration
 	samplers []SamplerFunc
+	// clock is the time of the recorded events and samples
+	clock clock.Clock
 
 	lock    sync.Mutex
 	events  []*Event
 	samples []*sample
+	// evicted is the number of events removed from memory, the cursor of events[0]
+	evicted int64
+
+	storage StorageConfig
+	// spillLock serializes the segment I/O, which runs without the monitor lock. It is
+	// taken before the monitor lock when both are held.
+	spillLock sync.Mutex
+	spill     *segmentStore
+	// pendingEvents and pendingSamples are evicted from memory but not yet written to
+	// the spill directory, expireBefore is the retention cutoff not yet applied to it
+	pendingEvents  []*Event
+	pendingSamples []*sample
+	expireBefore   time.Time
+
+	// aggregation is the window repeated events are collapsed within, see SetAggregation
+	aggregation   time.Duration
+	lastByLocator map[string]*Event
+	repeats       map[*Event]*repeat
+
+	metrics *monitorMetrics
+	rules   *Rules
 }
 
 // NewMonitor creates a monitor with the default sampling interval.
 func NewMonitor() *Monitor {
 	return &Monitor{
 		interval: 15 * time.Second,
+		clock:    clock.RealClock{},
+		metrics:  newMonitorMetrics(),
+	}
+}
+
+// NewMonitorWithStorage creates a monitor with the default sampling interval that keeps
+// the data bounded by storage.
+func NewMonitorWithStorage(storage StorageConfig) (*Monitor, error) {
+	m := NewMonitor()
+	m.storage = storage
+	if len(storage.SpillDir) > 0 {
+		spill, err := newSegmentStore(storage.SpillDir)
+		if err != nil {
+			return nil, err
+		}
+		m.spill = spill
 	}
+	return m, nil
 }
 
 var _ Interface = &Monitor{}
@@ -35,11 +77,11 @@ func (m *Monitor) StartSampling(ctx context.Context) {
 		return
 	}
 	go func() {
-		ticker := time.NewTicker(m.interval)
+		ticker := m.clock.NewTicker(m.interval)
 		defer ticker.Stop()
 		for {
 			select {
-			case <-ticker.C:
+			case <-ticker.C():
 			case <-ctx.Done():
 				m.sample()
 				return
@@ -58,6 +100,14 @@ func (m *Monitor) AddSampler(fn SamplerFunc) {
 	m.samplers = append(m.samplers, fn)
 }
 
+// SetRules sets the rules applied to every condition recorded or sampled after
+// this call.
+func (m *Monitor) SetRules(rules *Rules) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.rules = rules
+}
+
 // Record captures one or more conditions at the current time. All conditions are recorded
 // in monotonic order as Event objects.
 func (m *Monitor) Record(conditions ...Condition) {
@@ -65,43 +115,223 @@ func (m *Monitor) Record(conditions ...Condition) {
 		return
 	}
 	m.lock.Lock()
-	defer m.lock.Unlock()
-	t := time.Now().UTC()
+	t := m.clock.Now().UTC()
+	recorded := make([]Condition, 0, len(conditions))
 	for _, condition := range conditions {
-		m.events = append(m.events, &Event{
+		condition, ok := m.rules.Apply(condition)
+		if !ok {
+			continue
+		}
+		recorded = append(recorded, condition)
+		event := &Event{
 			At:        t,
 			Condition: condition,
-		})
+		}
+		if m.aggregateLocked(event) {
+			continue
+		}
+		m.events = append(m.events, event)
+	}
+	m.metrics.recordEvents(recorded)
+	flush := m.trimLocked(t)
+	m.lock.Unlock()
+	if flush {
+		m.flush()
 	}
 }
 
 func (m *Monitor) sample() {
 	m.lock.Lock()
-	samplers := m.samplers
+	samplers, rules := m.samplers, m.rules
 	m.lock.Unlock()
 
-	now := time.Now().UTC()
+	now := m.clock.Now().UTC()
 	var conditions []*Condition
 	for _, fn := range samplers {
-		conditions = append(conditions, fn(now)...)
+		for _, condition := range fn(now) {
+			if rules == nil {
+				conditions = append(conditions, condition)
+				continue
+			}
+			if applied, ok := rules.Apply(*condition); ok {
+				conditions = append(conditions, &applied)
+			}
+		}
 	}
+	m.metrics.recordSample(now, conditions)
 	if len(conditions) == 0 {
 		return
 	}
 
 	m.lock.Lock()
-	defer m.lock.Unlock()
-	t := time.Now().UTC()
+	t := m.clock.Now().UTC()
 	m.samples = append(m.samples, &sample{
 		at:         t,
 		conditions: conditions,
 	})
+	flush := m.trimLocked(t)
+	m.lock.Unlock()
+	if flush {
+		m.flush()
+	}
 }
 
-func (m *Monitor) snapshot() ([]*sample, []*Event) {
+// trimLocked discards the data older than the retention and moves the data over the
+// in memory limit to the pending data of the spill directory. The oldest half of the
+// limit is evicted at once so segments are not written for every event. It returns true
+// if the spill directory has to be updated with flush once the lock is released.
+func (m *Monitor) trimLocked(now time.Time) bool {
+	if retention := m.storage.Retention; retention > 0 {
+		cutoff := now.Add(-retention)
+		n := sort.Search(len(m.events), func(i int) bool { return !m.events[i].At.Before(cutoff) })
+		m.evictEventsLocked(n, false)
+		n = sort.Search(len(m.samples), func(i int) bool { return !m.samples[i].at.Before(cutoff) })
+		m.evictSamplesLocked(n, false)
+		n = sort.Search(len(m.pendingEvents), func(i int) bool { return !m.pendingEvents[i].At.Before(cutoff) })
+		m.pendingEvents = m.pendingEvents[n:]
+		n = sort.Search(len(m.pendingSamples), func(i int) bool { return !m.pendingSamples[i].at.Before(cutoff) })
+		m.pendingSamples = m.pendingSamples[n:]
+		if m.spill != nil {
+			m.expireBefore = cutoff
+		}
+	}
+	if limit := m.storage.MaxEventsInMemory; limit > 0 {
+		if len(m.events) > limit {
+			m.evictEventsLocked(len(m.events)-limit/2, true)
+		}
+		if len(m.samples) > limit {
+			m.evictSamplesLocked(len(m.samples)-limit/2, true)
+		}
+	}
+	return len(m.pendingEvents) > 0 || len(m.pendingSamples) > 0 || !m.expireBefore.IsZero()
+}
+
+func (m *Monitor) evictEventsLocked(n int, spill bool) {
+	if n == 0 {
+		return
+	}
+	if spill && m.spill != nil {
+		events := m.events[:n]
+		if len(m.repeats) > 0 {
+			// spilled events keep the number of occurrences in their message
+			events = make([]*Event, 0, n)
+			for _, event := range m.events[:n] {
+				if r, ok := m.repeats[event]; ok && r.count > 1 {
+					event = &Event{At: event.At, Condition: event.Condition}
+					event.Message = fmt.Sprintf("%s (%d times)", normalizeMessage(event.Message), r.count)
+				}
+				events = append(events, event)
+			}
+		}
+		m.pendingEvents = append(m.pendingEvents, events...)
+	}
+	m.forgetLocked(m.events[:n])
+	// copy the remainder so the evicted events can be released
+	m.events = append([]*Event(nil), m.events[n:]...)
+	m.evicted += int64(n)
+}
+
+func (m *Monitor) evictSamplesLocked(n int, spill bool) {
+	if n == 0 {
+		return
+	}
+	if spill && m.spill != nil {
+		m.pendingSamples = append(m.pendingSamples, m.samples[:n]...)
+	}
+	m.samples = append([]*sample(nil), m.samples[n:]...)
+}
+
+// flush writes the pending events and samples to the spill directory and removes the
+// segments past the retention. The pending data is swapped out under the monitor lock
+// and written without it, so recording is not blocked by the disk.
+func (m *Monitor) flush() {
+	m.spillLock.Lock()
+	defer m.spillLock.Unlock()
+	m.lock.Lock()
+	events, samples, cutoff := m.pendingEvents, m.pendingSamples, m.expireBefore
+	m.pendingEvents, m.pendingSamples, m.expireBefore = nil, nil, time.Time{}
+	m.lock.Unlock()
+
+	if m.spill == nil {
+		return
+	}
+	if err := m.spill.writeEvents(events); err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+	}
+	if err := m.spill.writeSamples(samples); err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+	}
+	if !cutoff.IsZero() {
+		m.spill.expire(cutoff)
+	}
+}
+
+// snapshot returns the samples and events that may fall between from and to, reading
+// the spilled segments if the interval starts before the data in memory.
+func (m *Monitor) snapshot(from, to time.Time) ([]*sample, []*Event, map[*Event]repeat) {
+	// the spill lock keeps the pending data from being written while the segments are
+	// read, the monitor lock is only held to copy the data in memory
+	m.spillLock.Lock()
+	defer m.spillLock.Unlock()
+	m.lock.Lock()
+	samples, events := m.samples, m.events
+	if len(m.pendingSamples) > 0 {
+		samples = append(append([]*sample(nil), m.pendingSamples...), samples...)
+	}
+	if len(m.pendingEvents) > 0 {
+		events = append(append([]*Event(nil), m.pendingEvents...), events...)
+	}
+	var repeats map[*Event]repeat
+	if len(m.repeats) > 0 {
+		repeats = make(map[*Event]repeat, len(m.repeats))
+		for event, r := range m.repeats {
+			repeats[event] = *r
+		}
+	}
+	m.lock.Unlock()
+	if m.spill == nil {
+		return samples, events, repeats
+	}
+	if len(m.spill.samples) > 0 && (len(samples) == 0 || from.Before(samples[0].at)) {
+		spilled, err := m.spill.readSamples(from, to)
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+		}
+		samples = append(spilled, samples...)
+	}
+	if len(m.spill.events) > 0 && (len(events) == 0 || from.Before(events[0].At)) {
+		spilled, err := m.spill.readEvents(from, to)
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+		}
+		events = append(spilled, events...)
+	}
+	return samples, events, repeats
+}
+
+// EventsSince returns the events recorded after cursor in the order they were recorded
+// and the cursor to pass to the next call. A zero cursor returns every event in memory.
+// Events evicted from memory before they were returned are skipped.
+func (m *Monitor) EventsSince(cursor int64) (EventIntervals, int64) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	return m.samples, m.events
+	end := m.evicted + int64(len(m.events))
+	switch {
+	case cursor < m.evicted:
+		cursor = m.evicted
+	case cursor > end:
+		cursor = end
+	}
+	events := m.events[cursor-m.evicted:]
+	intervals := make(EventIntervals, 0, len(events))
+	for _, event := range events {
+		intervals = append(intervals, &EventInterval{
+			From:      event.At,
+			To:        event.At,
+			Condition: &event.Condition,
+		})
+	}
+	return intervals, end
 }
 
 // Conditions returns all conditions that were sampled in the interval
@@ -111,15 +341,16 @@ func (m *Monitor) snapshot() ([]*sample, []*Event) {
 // returned with from == to. No duplicate conditions are returned
 // unless a sampling interval did not report that value.
 func (m *Monitor) Conditions(from, to time.Time) EventIntervals {
-	samples, _ := m.snapshot()
+	samples, _, _ := m.snapshot(from, to)
 	return filterSamples(samples, from, to)
 }
 
 // Events returns all events that occur between from and to, including
 // any sampled conditions that were encountered during that period.
-// EventIntervals are returned in order of their occurrence.
+// EventIntervals are returned in order of their occurrence. Repeated
+// events collapsed by aggregation span their first to last occurrence.
 func (m *Monitor) Events(from, to time.Time) EventIntervals {
-	samples, events := m.snapshot()
+	samples, events, repeats := m.snapshot(from, to)
 	intervals := filterSamples(samples, from, to)
 	events = filterEvents(events, from, to)
 
@@ -129,13 +360,7 @@ func (m *Monitor) Events(from, to time.Time) EventIntervals {
 		if i > 0 && events[i-1].At.After(events[i].At) {
 			fmt.Printf("ERROR: event %d out of order\n  %#v\n  %#v\n", i, events[i-1], events[i])
 		}
-		at := events[i].At
-		condition := &events[i].Condition
-		intervals = append(intervals, &EventInterval{
-			From:      at,
-			To:        at,
-			Condition: condition,
-		})
+		intervals = append(intervals, aggregatedInterval(events[i], repeats))
 	}
 	if mustSort {
 		sort.Sort(intervals)
@@ -8,6 +8,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/tools/cache"
 
@@ -15,7 +16,7 @@ import (
 	configclientset "github.com/openshift/client-go/config/clientset/versioned"
 )
 
-func startClusterOperatorMonitoring(ctx context.Context, m Recorder, client configclientset.Interface) {
+func startClusterOperatorMonitoring(ctx context.Context, m Recorder, clock clock.Clock, client configclientset.Interface) {
 	coInformer := cache.NewSharedIndexInformer(
 		NewErrorRecordingListWatcher(m, &cache.ListWatch{
 			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
@@ -74,7 +75,7 @@ func startClusterOperatorMonitoring(ctx context.Context, m Recorder, client conf
 		},
 	}
 
-	startTime := time.Now().Add(-time.Minute)
+	startTime := clock.Now().Add(-time.Minute)
 	coInformer.AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj interface{}) {
@@ -0,0 +1,112 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	informercorev1 "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// pclusterLocatorPrefix is prepended to the locator of every condition recorded
+	// for the physical cluster.
+	pclusterLocatorPrefix = "pcluster/"
+
+	// syncerNamespacePrefix is the prefix of the namespaces the syncers are deployed to
+	syncerNamespacePrefix = "kcp-syncer-"
+	// syncedNamespaceLabel is set by the syncer on the namespaces it creates for the
+	// namespaces of a workspace
+	syncedNamespaceLabel = "internal.workload.kcp.dev/cluster"
+)
+
+// startPClusterMonitoring monitors the pods of the syncer and synced namespaces and the
+// nodes of the physical cluster referenced by kubeconfig.
+func startPClusterMonitoring(ctx context.Context, m Recorder, clock clock.Clock, kubeconfig string) error {
+	client, err := newPClusterClient(kubeconfig)
+	if err != nil {
+		return err
+	}
+	pm := &prefixRecorder{recorder: m, prefix: pclusterLocatorPrefix}
+
+	namespaceInformer := informercorev1.NewNamespaceInformer(client, time.Hour, nil)
+	go namespaceInformer.Run(ctx.Done())
+	go func() {
+		// pods are only accepted once the synced namespaces are known
+		if !cache.WaitForCacheSync(ctx.Done(), namespaceInformer.HasSynced) {
+			return
+		}
+		startPodMonitoring(ctx, pm, clock, client, filterToSyncerNamespaces(namespaceInformer.GetStore()))
+	}()
+	startNodeMonitoring(ctx, pm, client)
+	return nil
+}
+
+func newPClusterClient(kubeconfig string) (kubernetes.Interface, error) {
+	clusterConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not load the pcluster client configuration: %v", err)
+	}
+	return kubernetes.NewForConfig(clusterConfig)
+}
+
+// filterToSyncerNamespaces keeps the objects in the syncer namespaces and in the
+// namespaces a syncer created, which carry the synced namespace label.
+func filterToSyncerNamespaces(namespaces cache.Store) func(runtime.Object) bool {
+	return func(obj runtime.Object) bool {
+		m, ok := obj.(metav1.Object)
+		if !ok {
+			return true
+		}
+		ns := m.GetNamespace()
+		if strings.HasPrefix(ns, syncerNamespacePrefix) {
+			return true
+		}
+		item, exists, err := namespaces.GetByKey(ns)
+		if err != nil || !exists {
+			return false
+		}
+		namespace, ok := item.(*corev1.Namespace)
+		if !ok {
+			return false
+		}
+		_, synced := namespace.Labels[syncedNamespaceLabel]
+		return synced
+	}
+}
+
+// prefixRecorder prepends a prefix to the locator of the conditions recorded and
+// sampled through it.
+type prefixRecorder struct {
+	recorder Recorder
+	prefix   string
+}
+
+func (r *prefixRecorder) Record(conditions ...Condition) {
+	prefixed := make([]Condition, 0, len(conditions))
+	for _, condition := range conditions {
+		condition.Locator = r.prefix + condition.Locator
+		prefixed = append(prefixed, condition)
+	}
+	r.recorder.Record(prefixed...)
+}
+
+func (r *prefixRecorder) AddSampler(fn SamplerFunc) {
+	r.recorder.AddSampler(func(now time.Time) []*Condition {
+		var prefixed []*Condition
+		for _, condition := range fn(now) {
+			copied := *condition
+			copied.Locator = r.prefix + copied.Locator
+			prefixed = append(prefixed, &copied)
+		}
+		return prefixed
+	})
+}
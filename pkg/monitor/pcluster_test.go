@@ -0,0 +1,57 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func Test_filterToSyncerNamespaces(t *testing.T) {
+	namespaces := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	namespaces.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kcp-abcdef", Labels: map[string]string{syncedNamespaceLabel: "2kx8ddpq"}}})
+	namespaces.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+	filter := filterToSyncerNamespaces(namespaces)
+
+	tests := []struct {
+		namespace string
+		want      bool
+	}{
+		{namespace: "kcp-syncer-pcluster-1a2b3c4d", want: true},
+		{namespace: "kcp-abcdef", want: true},
+		{namespace: "default", want: false},
+		{namespace: "missing", want: false},
+	}
+	for _, tt := range tests {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: tt.namespace, Name: "a"}}
+		if got := filter(pod); got != tt.want {
+			t.Errorf("%s: expected %t, got %t", tt.namespace, tt.want, got)
+		}
+	}
+}
+
+func Test_prefixRecorder(t *testing.T) {
+	m := NewMonitor()
+	r := &prefixRecorder{recorder: m, prefix: pclusterLocatorPrefix}
+
+	condition := &Condition{Level: Warning, Locator: "node/a", Message: "node is not ready"}
+	r.AddSampler(func(time.Time) []*Condition { return []*Condition{condition} })
+	m.sample()
+	m.sample()
+	r.Record(Condition{Level: Warning, Locator: "ns/kcp-syncer-a pod/b node/a", Message: "deleted"})
+
+	if condition.Locator != "node/a" {
+		t.Errorf("sampled condition was modified: %#v", condition)
+	}
+	for _, interval := range m.Conditions(time.Time{}, time.Time{}) {
+		if interval.Locator != "pcluster/node/a" {
+			t.Errorf("unexpected sampled locator: %s", interval.Locator)
+		}
+	}
+	events := m.Events(time.Time{}, time.Time{})
+	if len(events) == 0 || events[len(events)-1].Locator != "pcluster/ns/kcp-syncer-a pod/b node/a" {
+		t.Errorf("unexpected events: %v", events)
+	}
+}
@@ -8,12 +8,14 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 )
 
-func startPodMonitoring(ctx context.Context, m Recorder, client kubernetes.Interface) {
+// startPodMonitoring records the changes of the pods the filter accepts.
+func startPodMonitoring(ctx context.Context, m Recorder, clock clock.Clock, client kubernetes.Interface, filter func(runtime.Object) bool) {
 	podInformer := cache.NewSharedIndexInformer(
 		NewErrorRecordingListWatcher(m, &cache.ListWatch{
 			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
@@ -22,13 +24,11 @@ func startPodMonitoring(ctx context.Context, m Recorder, client kubernetes.Inter
 					last := 0
 					for i := range items.Items {
 						item := &items.Items[i]
-						if !filterToSystemNamespaces(item) {
+						if !filter(item) {
 							continue
 						}
-						if i != last {
-							items.Items[last] = *item
-							last++
-						}
+						items.Items[last] = *item
+						last++
 					}
 					items.Items = items.Items[:last]
 				}
@@ -38,7 +38,7 @@ func startPodMonitoring(ctx context.Context, m Recorder, client kubernetes.Inter
 				w, err := client.CoreV1().Pods("").Watch(options)
 				if err == nil {
 					w = watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
-						return in, filterToSystemNamespaces(in.Object)
+						return in, filter(in.Object)
 					})
 				}
 				return w, err
@@ -222,7 +222,7 @@ func startPodMonitoring(ctx context.Context, m Recorder, client kubernetes.Inter
 		},
 	}
 
-	startTime := time.Now().Add(-time.Minute)
+	startTime := clock.Now().Add(-time.Minute)
 	podInformer.AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj interface{}) {
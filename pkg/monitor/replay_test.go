@@ -0,0 +1,348 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configfake "github.com/openshift/client-go/config/clientset/versioned/fake"
+)
+
+// replayStart is the time the clock of a replay starts at, the times of a fixture are
+// offsets from it.
+var replayStart = time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+
+const (
+	// replayTick is the step the clock of a replay advances by, the interval of the
+	// API samplers.
+	replayTick = time.Second
+	// replayQuiet is how long a replay waits for the sources to stop recording after
+	// the clock advances or an object changes.
+	replayQuiet = 20 * time.Millisecond
+)
+
+// replayFixture is a recorded run of the monitor: the sources replayed, the changes the
+// sources observed and the events the monitor is expected to report.
+type replayFixture struct {
+	// Sources are the replayed sources: api, events, pods, nodes or clusteroperators.
+	Sources []string `json:"sources"`
+	// Objects exist before the sources start, they are served by the List responses.
+	Objects []runtime.RawExtension `json:"objects,omitempty"`
+	// Steps are the recorded changes, in the order of their offsets.
+	Steps []replayStep `json:"steps"`
+	// Until is the offset the replay ends at, it is at least the offset of the last step.
+	Until metav1.Duration `json:"until"`
+	// Events are the expected events and sampled conditions as
+	// "<from>-<to> <level> <locator> <message>" with offsets from the start.
+	Events []string `json:"events"`
+}
+
+// replayStep is either a watch event of an object or a change of the API availability.
+type replayStep struct {
+	// At is the offset of the step from the start of the replay.
+	At metav1.Duration `json:"at"`
+	// Type is the ADDED, MODIFIED or DELETED watch event of Object.
+	Type   watch.EventType      `json:"type,omitempty"`
+	Object runtime.RawExtension `json:"object,omitempty"`
+	// APIAvailable sets whether the stand-in API server responds to requests.
+	APIAvailable *bool `json:"apiAvailable,omitempty"`
+}
+
+// replayScheme decodes the objects of the fixtures.
+var replayScheme = runtime.NewScheme()
+
+func init() {
+	if err := clientgoscheme.AddToScheme(replayScheme); err != nil {
+		panic(err)
+	}
+	if err := configv1.Install(replayScheme); err != nil {
+		panic(err)
+	}
+}
+
+// replayHarness runs the monitor sources offline against fake clients and a stand-in API
+// server on a fake clock. The objects of a fixture are served by the List and Watch
+// responses of the fake clients.
+type replayHarness struct {
+	t       *testing.T
+	clock   *clock.FakeClock
+	monitor *Monitor
+
+	client       *fake.Clientset
+	configClient *configfake.Clientset
+	// watches counts the watches the sources have started on the fake clients
+	watches int32
+
+	api *replayAPIServer
+	// apiSamplers is the number of samplers that poll the API server every tick
+	apiSamplers int32
+}
+
+func newReplayHarness(t *testing.T) *replayHarness {
+	h := &replayHarness{
+		t:            t,
+		clock:        clock.NewFakeClock(replayStart),
+		monitor:      NewMonitor(),
+		client:       fake.NewSimpleClientset(),
+		configClient: configfake.NewSimpleClientset(),
+		api:          newReplayAPIServer(),
+	}
+	h.monitor.clock = h.clock
+	countWatches := func(clienttesting.Action) (bool, watch.Interface, error) {
+		atomic.AddInt32(&h.watches, 1)
+		return false, nil, nil
+	}
+	h.client.PrependWatchReactor("*", countWatches)
+	h.configClient.PrependWatchReactor("*", countWatches)
+	return h
+}
+
+// start starts the sources and waits for them to watch the fake clients and to record
+// the listed objects, the objects changed or the clock stepped before that would be
+// missed.
+func (h *replayHarness) start(ctx context.Context, sources []string) {
+	var watches int32
+	for _, name := range sources {
+		switch name {
+		case "api":
+			if err := startAPIMonitoring(ctx, h.monitor, &rest.Config{Host: h.api.URL, QPS: -1}); err != nil {
+				h.t.Fatal(err)
+			}
+			h.apiSamplers += 2
+		case "events":
+			startEventMonitoring(ctx, h.monitor, h.client)
+			watches++
+		case "pods":
+			startPodMonitoring(ctx, h.monitor, h.clock, h.client, filterToSystemNamespaces)
+			watches++
+		case "nodes":
+			startNodeMonitoring(ctx, h.monitor, h.client)
+			watches++
+		case "clusteroperators":
+			startClusterOperatorMonitoring(ctx, h.monitor, h.clock, h.configClient)
+			watches += 2
+		default:
+			h.t.Fatalf("unknown replay source %q", name)
+		}
+	}
+	h.waitFor(fmt.Sprintf("%d watches", watches), func() bool { return atomic.LoadInt32(&h.watches) >= watches })
+	// the handlers of the listed objects may still be running
+	h.settle()
+}
+
+// advance moves the clock one tick at a time to the offset, waiting for the API
+// samplers after every tick and sampling the monitor at every sampling interval.
+func (h *replayHarness) advance(offset time.Duration) {
+	for h.clock.Since(replayStart) < offset {
+		requests := h.api.requestCount()
+		h.clock.Step(replayTick)
+		if h.apiSamplers > 0 {
+			h.waitFor("the API samplers", func() bool { return h.api.requestCount() >= requests+h.apiSamplers })
+			h.settle()
+		}
+		if h.clock.Since(replayStart)%h.monitor.interval == 0 {
+			h.monitor.sample()
+		}
+	}
+}
+
+// apply replays a step on the fake clients or the API server.
+func (h *replayHarness) apply(step replayStep) {
+	if step.APIAvailable != nil {
+		h.api.setAvailable(*step.APIAvailable)
+	}
+	if len(step.Object.Raw) == 0 {
+		return
+	}
+	obj, gvk, tracker, err := h.decode(step.Object)
+	if err != nil {
+		h.t.Fatalf("%s: %v", step.At.Duration, err)
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	gvr, _ := meta.UnsafeGuessKindToResource(*gvk)
+	switch step.Type {
+	case watch.Added:
+		err = tracker.Add(obj)
+	case watch.Modified:
+		err = tracker.Update(gvr, obj, accessor.GetNamespace())
+	case watch.Deleted:
+		err = tracker.Delete(gvr, accessor.GetNamespace(), accessor.GetName())
+	default:
+		err = fmt.Errorf("unknown step type %q", step.Type)
+	}
+	if err != nil {
+		h.t.Fatalf("%s: %v", step.At.Duration, err)
+	}
+	h.settle()
+}
+
+// add adds the objects existing before the sources start to the fake clients.
+func (h *replayHarness) add(objects []runtime.RawExtension) {
+	for i, object := range objects {
+		obj, _, tracker, err := h.decode(object)
+		if err == nil {
+			err = tracker.Add(obj)
+		}
+		if err != nil {
+			h.t.Fatalf("object %d: %v", i, err)
+		}
+	}
+}
+
+// decode returns the object and the tracker of the fake client serving it.
+func (h *replayHarness) decode(object runtime.RawExtension) (runtime.Object, *schema.GroupVersionKind, clienttesting.ObjectTracker, error) {
+	obj, gvk, err := serializer.NewCodecFactory(replayScheme).UniversalDeserializer().Decode(object.Raw, nil, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if gvk.Group == configv1.GroupName {
+		return obj, gvk, h.configClient.Tracker(), nil
+	}
+	return obj, gvk, h.client.Tracker(), nil
+}
+
+// settle waits until the monitor has recorded nothing for replayQuiet.
+func (h *replayHarness) settle() {
+	_, cursor := h.monitor.EventsSince(0)
+	for {
+		time.Sleep(replayQuiet)
+		_, next := h.monitor.EventsSince(cursor)
+		if next == cursor {
+			return
+		}
+		cursor = next
+	}
+}
+
+func (h *replayHarness) waitFor(what string, fn func() bool) {
+	deadline := time.Now().Add(10 * time.Second)
+	for !fn() {
+		if time.Now().After(deadline) {
+			h.t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// events returns the events and sampled conditions of the monitor in the format of
+// replayFixture.Events.
+func (h *replayHarness) events() []string {
+	var events []string
+	for _, event := range h.monitor.Events(time.Time{}, time.Time{}) {
+		events = append(events, fmt.Sprintf("%s-%s %s %s %s", event.From.Sub(replayStart), event.To.Sub(replayStart), event.Level, event.Locator, event.Message))
+	}
+	return events
+}
+
+// replayAPIServer stands in for the API server polled by the API samplers. It serves
+// the kube-system namespace and nothing else while available.
+type replayAPIServer struct {
+	*httptest.Server
+
+	lock        sync.Mutex
+	unavailable bool
+	requests    int32
+}
+
+func newReplayAPIServer() *replayAPIServer {
+	s := &replayAPIServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *replayAPIServer) serve(w http.ResponseWriter, r *http.Request) {
+	defer atomic.AddInt32(&s.requests, 1)
+	s.lock.Lock()
+	unavailable := s.unavailable
+	s.lock.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case unavailable:
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","message":"the server is shutting down","reason":"ServiceUnavailable","code":503}`)
+	case r.URL.Path == "/api/v1/namespaces/kube-system":
+		fmt.Fprint(w, `{"kind":"Namespace","apiVersion":"v1","metadata":{"name":"kube-system"}}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","message":"%s not found","reason":"NotFound","code":404}`, r.URL.Path)
+	}
+}
+
+func (s *replayAPIServer) setAvailable(available bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.unavailable = !available
+}
+
+func (s *replayAPIServer) requestCount() int32 {
+	return atomic.LoadInt32(&s.requests)
+}
+
+// replay runs the monitor through the fixture at path and returns the reported events.
+func replay(t *testing.T, path string) ([]string, *replayFixture) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixture := &replayFixture{}
+	if err := yaml.UnmarshalStrict(data, fixture); err != nil {
+		t.Fatalf("could not read %s: %v", path, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := newReplayHarness(t)
+	defer h.api.Close()
+	h.add(fixture.Objects)
+	h.start(ctx, fixture.Sources)
+	for _, step := range fixture.Steps {
+		h.advance(step.At.Duration)
+		h.apply(step)
+	}
+	h.advance(fixture.Until.Duration)
+	return h.events(), fixture
+}
+
+func TestMonitor_Replay(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "replay", "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no replay fixtures")
+	}
+	for _, path := range paths {
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".yaml"), func(t *testing.T) {
+			events, fixture := replay(t, path)
+			if strings.Join(events, "\n") != strings.Join(fixture.Events, "\n") {
+				t.Errorf("unexpected events:\n%s\nexpected:\n%s", strings.Join(events, "\n"), strings.Join(fixture.Events, "\n"))
+			}
+		})
+	}
+}
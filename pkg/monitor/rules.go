@@ -0,0 +1,129 @@
+package monitor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Rule reclassifies the conditions whose locator and message match its patterns.
+// A rule with neither pattern matches every condition.
+type Rule struct {
+	// Locator is a regular expression matched against the condition locator.
+	Locator string `json:"locator,omitempty"`
+	// Message is a regular expression matched against the condition message.
+	Message string `json:"message,omitempty"`
+
+	// Level, if set, replaces the level of the condition. One of Info, Warning or Error.
+	Level string `json:"level,omitempty"`
+	// Drop discards the condition.
+	Drop bool `json:"drop,omitempty"`
+	// Issue, if set, is a link to a known issue appended to the condition message.
+	Issue string `json:"issue,omitempty"`
+}
+
+// RuleSet is the format of a rules file.
+type RuleSet struct {
+	Rules []Rule `json:"rules"`
+}
+
+type compiledRule struct {
+	Rule
+	locator *regexp.Regexp
+	message *regexp.Regexp
+	level   *EventLevel
+}
+
+// Rules is an ordered list of rules applied to every condition the monitor records.
+// The first rule that matches a condition is the only one applied.
+type Rules struct {
+	rules []compiledRule
+}
+
+// LoadRules reads a YAML or JSON rules file, for example:
+//
+//	rules:
+//	- locator: "^ns/kube-system "
+//	  message: "BackOff"
+//	  level: Info
+//	- message: "failed contacting the API: .*etcdserver: leader changed"
+//	  level: Warning
+//	  issue: https://github.com/kcp-dev/kcp/issues/0000
+func LoadRules(path string) (*Rules, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read monitor rules: %v", err)
+	}
+	var ruleSet RuleSet
+	if err := yaml.UnmarshalStrict(data, &ruleSet); err != nil {
+		return nil, fmt.Errorf("could not parse monitor rules %s: %v", path, err)
+	}
+	return NewRules(ruleSet.Rules...)
+}
+
+// NewRules compiles the provided rules in order.
+func NewRules(rules ...Rule) (*Rules, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+	for i, rule := range rules {
+		c := compiledRule{Rule: rule}
+		var err error
+		if len(rule.Locator) > 0 {
+			if c.locator, err = regexp.Compile(rule.Locator); err != nil {
+				return nil, fmt.Errorf("rule %d has an invalid locator pattern: %v", i, err)
+			}
+		}
+		if len(rule.Message) > 0 {
+			if c.message, err = regexp.Compile(rule.Message); err != nil {
+				return nil, fmt.Errorf("rule %d has an invalid message pattern: %v", i, err)
+			}
+		}
+		if len(rule.Level) > 0 {
+			level, err := ParseEventLevel(rule.Level)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: %v", i, err)
+			}
+			c.level = &level
+		}
+		compiled = append(compiled, c)
+	}
+	return &Rules{rules: compiled}, nil
+}
+
+// Apply returns the condition reclassified by the first matching rule and false if
+// the condition should be dropped. Conditions no rule matches are returned unchanged.
+func (r *Rules) Apply(condition Condition) (Condition, bool) {
+	if r == nil {
+		return condition, true
+	}
+	for _, rule := range r.rules {
+		if rule.locator != nil && !rule.locator.MatchString(condition.Locator) {
+			continue
+		}
+		if rule.message != nil && !rule.message.MatchString(condition.Message) {
+			continue
+		}
+		if rule.Drop {
+			return condition, false
+		}
+		if rule.level != nil {
+			condition.Level = *rule.level
+		}
+		if len(rule.Issue) > 0 {
+			condition.Message = fmt.Sprintf("%s (known issue %s)", condition.Message, rule.Issue)
+		}
+		return condition, true
+	}
+	return condition, true
+}
+
+// ParseEventLevel returns the level with the provided name, one of Info, Warning or Error.
+func ParseEventLevel(name string) (EventLevel, error) {
+	for i, levelName := range eventLevelName {
+		if levelName == name {
+			return EventLevel(i), nil
+		}
+	}
+	return Info, fmt.Errorf("unrecognized event level %q, must be one of %v", name, eventLevelName)
+}
@@ -0,0 +1,66 @@
+package monitor
+
+import (
+	"testing"
+)
+
+func TestRules_Apply(t *testing.T) {
+	rules, err := NewRules(
+		Rule{Locator: "^ns/kube-system ", Drop: true},
+		Rule{Message: "leader changed", Level: "Warning", Issue: "https://github.com/kcp-dev/kcp/issues/1"},
+		Rule{Locator: "^kube-apiserver$", Level: "Info"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		condition Condition
+		want      Condition
+		dropped   bool
+	}{
+		{
+			name:      "no match",
+			condition: Condition{Level: Error, Locator: "workspace/root:a", Message: "deleted"},
+			want:      Condition{Level: Error, Locator: "workspace/root:a", Message: "deleted"},
+		},
+		{
+			name:      "dropped",
+			condition: Condition{Level: Warning, Locator: "ns/kube-system pod/a", Message: "BackOff"},
+			dropped:   true,
+		},
+		{
+			name:      "level and issue",
+			condition: Condition{Level: Error, Locator: "kube-apiserver", Message: "failed contacting the API: etcdserver: leader changed"},
+			want:      Condition{Level: Warning, Locator: "kube-apiserver", Message: "failed contacting the API: etcdserver: leader changed (known issue https://github.com/kcp-dev/kcp/issues/1)"},
+		},
+		{
+			name:      "first match wins",
+			condition: Condition{Level: Error, Locator: "kube-apiserver", Message: "Kube API started failing"},
+			want:      Condition{Level: Info, Locator: "kube-apiserver", Message: "Kube API started failing"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := rules.Apply(tt.condition)
+			if ok == tt.dropped {
+				t.Fatalf("expected dropped=%t, got %t", tt.dropped, !ok)
+			}
+			if ok && got != tt.want {
+				t.Errorf("unexpected condition: %#v", got)
+			}
+		})
+	}
+}
+
+func TestNewRules_Invalid(t *testing.T) {
+	for _, rule := range []Rule{
+		{Locator: "("},
+		{Message: "["},
+		{Level: "Critical"},
+	} {
+		if _, err := NewRules(rule); err == nil {
+			t.Errorf("expected an error for %#v", rule)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"sync"
 	"time"
+
+	"k8s.io/apimachinery/pkg/util/clock"
 )
 
 type ConditionalSampler interface {
@@ -16,16 +18,22 @@ type sampler struct {
 }
 
 func StartSampling(ctx context.Context, recorder Recorder, interval time.Duration, sampleFn func(previous bool) (*Condition, bool)) ConditionalSampler {
+	return startSampling(ctx, recorder, clock.RealClock{}, interval, sampleFn)
+}
+
+// startSampling is StartSampling with the ticks of interval measured on clock.
+func startSampling(ctx context.Context, recorder Recorder, clock clock.Clock, interval time.Duration, sampleFn func(previous bool) (*Condition, bool)) ConditionalSampler {
 	s := &sampler{
 		available: true,
 	}
 
+	// the ticker is created before returning so the first tick is interval from now
+	ticker := clock.NewTicker(interval)
 	go func() {
-		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 		for {
 			select {
-			case <-ticker.C:
+			case <-ticker.C():
 			case <-ctx.Done():
 				return
 			}
@@ -0,0 +1,99 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/client-go/rest"
+)
+
+// SourceFunc starts recording the conditions of one area of the cluster to m until
+// ctx is finished. clusterConfig references the cluster the monitor was started for.
+type SourceFunc func(ctx context.Context, m Recorder, clusterConfig *rest.Config) error
+
+type source struct {
+	name  string
+	start SourceFunc
+}
+
+var (
+	registryLock sync.Mutex
+	registry     []source
+)
+
+// RegisterSource adds a source that every monitor started afterwards runs, unless it
+// is deselected by name. Test packages register their sources from init functions.
+// RegisterSource panics if a source with the same name is already registered.
+func RegisterSource(name string, fn SourceFunc) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	for _, s := range registry {
+		if s.name == name {
+			panic(fmt.Sprintf("monitor source %q is already registered", name))
+		}
+	}
+	registry = append(registry, source{name: name, start: fn})
+}
+
+func registeredSources() []source {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	return append([]source(nil), registry...)
+}
+
+// sourceEnabled returns true if the source is selected by the selection, see
+// Config.Monitors. A selection of negations only disables the sources it names.
+func sourceEnabled(name string, selection []string) bool {
+	// every source is enabled unless the selection names the sources to run
+	all := true
+	for _, s := range selection {
+		if !strings.HasPrefix(s, "-") {
+			all = false
+			break
+		}
+	}
+	for _, s := range selection {
+		switch s {
+		case name:
+			return true
+		case "-" + name:
+			return false
+		case "*":
+			all = true
+		}
+	}
+	return all
+}
+
+// validateSourceSelection returns an error if the selection names a source that does
+// not exist or if two sources have the same name.
+func validateSourceSelection(sources []source, selection []string) error {
+	known := make(map[string]struct{}, len(sources))
+	for _, s := range sources {
+		if _, ok := known[s.name]; ok {
+			return fmt.Errorf("monitor source %q is registered more than once", s.name)
+		}
+		known[s.name] = struct{}{}
+	}
+	var unknown []string
+	for _, s := range selection {
+		if s == "*" {
+			continue
+		}
+		if _, ok := known[strings.TrimPrefix(s, "-")]; !ok {
+			unknown = append(unknown, s)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(known))
+	for name := range known {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("unknown monitor sources %s, must be one of %s", strings.Join(unknown, ", "), strings.Join(names, ", "))
+}
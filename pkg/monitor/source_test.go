@@ -0,0 +1,52 @@
+package monitor
+
+import (
+	"testing"
+)
+
+func Test_sourceEnabled(t *testing.T) {
+	tests := []struct {
+		selection []string
+		enabled   []string
+	}{
+		{selection: nil, enabled: []string{"api", "events", "syncer"}},
+		{selection: []string{"*"}, enabled: []string{"api", "events", "syncer"}},
+		{selection: []string{"*", "-events"}, enabled: []string{"api", "syncer"}},
+		{selection: []string{"-events", "*"}, enabled: []string{"api", "syncer"}},
+		{selection: []string{"api", "syncer"}, enabled: []string{"api", "syncer"}},
+		{selection: []string{"-api"}, enabled: []string{"events", "syncer"}},
+		{selection: []string{"-api", "-syncer"}, enabled: []string{"events"}},
+		{selection: []string{"-api", "syncer"}, enabled: []string{"syncer"}},
+	}
+	for _, tt := range tests {
+		var enabled []string
+		for _, name := range []string{"api", "events", "syncer"} {
+			if sourceEnabled(name, tt.selection) {
+				enabled = append(enabled, name)
+			}
+		}
+		if len(enabled) != len(tt.enabled) {
+			t.Errorf("%v: expected %v, got %v", tt.selection, tt.enabled, enabled)
+			continue
+		}
+		for i := range enabled {
+			if enabled[i] != tt.enabled[i] {
+				t.Errorf("%v: expected %v, got %v", tt.selection, tt.enabled, enabled)
+				break
+			}
+		}
+	}
+}
+
+func Test_validateSourceSelection(t *testing.T) {
+	sources := []source{{name: "api"}, {name: "events"}}
+	if err := validateSourceSelection(sources, []string{"*", "-events", "api"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := validateSourceSelection(sources, []string{"*", "-syncer"}); err == nil {
+		t.Errorf("expected an error for an unknown source")
+	}
+	if err := validateSourceSelection(append(sources, source{name: "api"}), nil); err == nil {
+		t.Errorf("expected an error for a duplicate source")
+	}
+}
@@ -0,0 +1,194 @@
+package monitor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StorageConfig bounds the data a monitor keeps. The zero value keeps everything in
+// memory for the lifetime of the monitor, which is what a single test run needs.
+type StorageConfig struct {
+	// Retention, if set, discards the events and samples older than this.
+	Retention time.Duration
+	// MaxEventsInMemory, if set, is the number of events and the number of samples kept
+	// in memory. Older data is written to SpillDir, or discarded if SpillDir is not set.
+	MaxEventsInMemory int
+	// SpillDir is the directory the segment files holding the data that no longer
+	// fits in memory are written to.
+	SpillDir string
+}
+
+// segment is a file holding a batch of events or samples written out of memory, in
+// the order they were recorded.
+type segment struct {
+	path     string
+	from, to time.Time
+}
+
+// storedSample is the on disk format of a sample.
+type storedSample struct {
+	At         time.Time
+	Conditions []Condition
+}
+
+// segmentStore writes the data evicted from memory to segment files in dir. It is
+// protected by the spill lock of the monitor.
+type segmentStore struct {
+	dir     string
+	next    int
+	events  []segment
+	samples []segment
+}
+
+func newSegmentStore(dir string) (*segmentStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("could not create the monitor spill directory: %v", err)
+	}
+	return &segmentStore{dir: dir}, nil
+}
+
+func (s *segmentStore) writeEvents(events []*Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	seg, err := s.write("events", len(events), func(i int) interface{} { return events[i] })
+	if err != nil {
+		return err
+	}
+	seg.from, seg.to = events[0].At, events[len(events)-1].At
+	s.events = append(s.events, seg)
+	return nil
+}
+
+func (s *segmentStore) writeSamples(samples []*sample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	seg, err := s.write("samples", len(samples), func(i int) interface{} {
+		stored := storedSample{At: samples[i].at, Conditions: make([]Condition, 0, len(samples[i].conditions))}
+		for _, condition := range samples[i].conditions {
+			stored.Conditions = append(stored.Conditions, *condition)
+		}
+		return stored
+	})
+	if err != nil {
+		return err
+	}
+	seg.from, seg.to = samples[0].at, samples[len(samples)-1].at
+	s.samples = append(s.samples, seg)
+	return nil
+}
+
+func (s *segmentStore) write(kind string, n int, item func(int) interface{}) (segment, error) {
+	seg := segment{path: filepath.Join(s.dir, fmt.Sprintf("%s-%06d.jsonl", kind, s.next))}
+	s.next++
+	f, err := os.Create(seg.path)
+	if err != nil {
+		return seg, fmt.Errorf("could not create monitor segment: %v", err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	encoder := json.NewEncoder(w)
+	for i := 0; i < n; i++ {
+		if err := encoder.Encode(item(i)); err != nil {
+			return seg, fmt.Errorf("could not write monitor segment %s: %v", seg.path, err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return seg, fmt.Errorf("could not write monitor segment %s: %v", seg.path, err)
+	}
+	return seg, f.Close()
+}
+
+// expire removes the segments that only hold data from before cutoff.
+func (s *segmentStore) expire(cutoff time.Time) {
+	s.events = expireSegments(s.events, cutoff)
+	s.samples = expireSegments(s.samples, cutoff)
+}
+
+func expireSegments(segments []segment, cutoff time.Time) []segment {
+	i := 0
+	for ; i < len(segments) && segments[i].to.Before(cutoff); i++ {
+		os.Remove(segments[i].path)
+	}
+	return segments[i:]
+}
+
+// readEvents returns the events of the segments overlapping the interval from and to,
+// a zero time leaves that end of the interval open.
+func (s *segmentStore) readEvents(from, to time.Time) ([]*Event, error) {
+	var events []*Event
+	for _, seg := range overlapping(s.events, from, to) {
+		err := readSegment(seg.path, func(decoder *json.Decoder) error {
+			event := &Event{}
+			if err := decoder.Decode(event); err != nil {
+				return err
+			}
+			events = append(events, event)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// readSamples returns the samples of the segments overlapping the interval from and to.
+func (s *segmentStore) readSamples(from, to time.Time) ([]*sample, error) {
+	var samples []*sample
+	for _, seg := range overlapping(s.samples, from, to) {
+		err := readSegment(seg.path, func(decoder *json.Decoder) error {
+			var stored storedSample
+			if err := decoder.Decode(&stored); err != nil {
+				return err
+			}
+			s := &sample{at: stored.At, conditions: make([]*Condition, 0, len(stored.Conditions))}
+			for i := range stored.Conditions {
+				s.conditions = append(s.conditions, &stored.Conditions[i])
+			}
+			samples = append(samples, s)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return samples, nil
+}
+
+func overlapping(segments []segment, from, to time.Time) []segment {
+	var result []segment
+	for _, seg := range segments {
+		if !from.IsZero() && !seg.to.After(from) {
+			continue
+		}
+		if !to.IsZero() && seg.from.After(to) {
+			continue
+		}
+		result = append(result, seg)
+	}
+	return result
+}
+
+func readSegment(path string, decode func(*json.Decoder) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not read monitor segment: %v", err)
+	}
+	defer f.Close()
+	decoder := json.NewDecoder(bufio.NewReader(f))
+	for {
+		if err := decode(decoder); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("could not read monitor segment %s: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package monitor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMonitor_Spill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "monitor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m, err := NewMonitorWithStorage(StorageConfig{MaxEventsInMemory: 4, SpillDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		m.Record(Condition{Message: fmt.Sprintf("%d", i)})
+	}
+	m.AddSampler(func(time.Time) []*Condition { return []*Condition{{Message: "sampled"}} })
+	for i := 0; i < 6; i++ {
+		m.sample()
+	}
+
+	if len(m.events) > 4 || len(m.samples) > 4 {
+		t.Fatalf("expected at most 4 events and samples in memory, got %d and %d", len(m.events), len(m.samples))
+	}
+	if segments, _ := filepath.Glob(filepath.Join(dir, "*.jsonl")); len(segments) == 0 {
+		t.Fatalf("expected segments to be written")
+	}
+
+	var messages []string
+	for _, event := range m.Events(time.Time{}, time.Time{}) {
+		if event.From.Equal(event.To) {
+			messages = append(messages, event.Message)
+		}
+	}
+	if fmt.Sprint(messages) != "[0 1 2 3 4 5 6 7 8 9]" {
+		t.Errorf("unexpected events: %v", messages)
+	}
+	conditions := m.Conditions(time.Time{}, time.Time{})
+	if len(conditions) != 1 || conditions[0].From.Equal(conditions[0].To) {
+		t.Errorf("expected a single interval spanning every sample: %v", conditions)
+	}
+}
+
+func TestMonitor_Retention(t *testing.T) {
+	m, err := NewMonitorWithStorage(StorageConfig{Retention: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Record(Condition{Message: "old"})
+	m.lock.Lock()
+	m.trimLocked(time.Now().Add(2 * time.Minute))
+	m.lock.Unlock()
+	m.Record(Condition{Message: "new"})
+
+	events := m.Events(time.Time{}, time.Time{})
+	if len(events) != 1 || events[0].Message != "new" {
+		t.Errorf("unexpected events: %v", events)
+	}
+}
+
+func TestMonitor_EventsSince(t *testing.T) {
+	m, err := NewMonitorWithStorage(StorageConfig{MaxEventsInMemory: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Record(Condition{Message: "1"}, Condition{Message: "2"})
+	events, cursor := m.EventsSince(0)
+	if len(events) != 2 || cursor != 2 {
+		t.Fatalf("unexpected events at cursor %d: %v", cursor, events)
+	}
+	if events, next := m.EventsSince(cursor); len(events) != 0 || next != cursor {
+		t.Fatalf("unexpected events at cursor %d: %v", next, events)
+	}
+
+	// evicting without a spill directory skips the evicted events
+	m.Record(Condition{Message: "3"}, Condition{Message: "4"}, Condition{Message: "5"})
+	events, cursor = m.EventsSince(cursor)
+	if len(events) != 2 || events[0].Message != "4" || cursor != 5 {
+		t.Errorf("unexpected events at cursor %d: %v", cursor, events)
+	}
+}
+
+func TestMonitor_SpillOutsideTheLock(t *testing.T) {
+	m, err := NewMonitorWithStorage(StorageConfig{MaxEventsInMemory: 2, SpillDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a segment being written or read holds the spill lock
+	m.spillLock.Lock()
+	recorded := make(chan struct{})
+	go func() {
+		m.Record(Condition{Message: "1"}, Condition{Message: "2"}, Condition{Message: "3"})
+		close(recorded)
+	}()
+
+	// the events are recorded and evicted while the segment cannot be written
+	done := make(chan EventIntervals)
+	go func() {
+		for {
+			if events, cursor := m.EventsSince(0); cursor == 3 {
+				done <- events
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	select {
+	case events := <-done:
+		if len(events) != 1 || events[0].Message != "3" {
+			t.Errorf("unexpected events in memory: %v", events)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("the monitor lock was held while writing the segment")
+	}
+	m.spillLock.Unlock()
+	<-recorded
+
+	// the evicted events are written once the spill lock is released
+	var messages []string
+	for _, event := range m.Events(time.Time{}, time.Time{}) {
+		messages = append(messages, event.Message)
+	}
+	if fmt.Sprint(messages) != "[1 2 3]" {
+		t.Errorf("unexpected events: %v", messages)
+	}
+}
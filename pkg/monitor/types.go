@@ -34,6 +34,16 @@ var eventString = []string{
 	"E",
 }
 
+var eventLevelName = []string{
+	"Info",
+	"Warning",
+	"Error",
+}
+
+func (l EventLevel) String() string {
+	return eventLevelName[l]
+}
+
 type Event struct {
 	Condition
 
@@ -0,0 +1,204 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// watchTimeout is the timeout requested for the probe watches, the server closing
+	// the watch once it expires is expected.
+	watchTimeout = 10 * time.Minute
+	// watchRetryInterval is the delay before a disrupted watch is established again.
+	watchRetryInterval = time.Second
+)
+
+// startWatchMonitoring keeps long-lived watches open on namespaces and workspaces in the
+// workspace of the cluster configuration and records every time one of them breaks.
+// A watch delivering neither events nor bookmarks is only recorded as disrupted if
+// bookmarkTimeout is set, kcp does not send bookmarks yet.
+func startWatchMonitoring(ctx context.Context, m Recorder, clock clock.Clock, client kubernetes.Interface, dynamicClient dynamic.Interface, bookmarkTimeout time.Duration) {
+	probes := []*watchProbe{
+		{
+			locator: "watch/namespaces",
+			lw: &cache.ListWatch{
+				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+					return client.CoreV1().Namespaces().List(options)
+				},
+				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+					return client.CoreV1().Namespaces().Watch(options)
+				},
+			},
+		},
+		{
+			locator: "watch/clusterworkspaces",
+			lw: &cache.ListWatch{
+				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+					return dynamicClient.Resource(clusterWorkspacesResource).List(options)
+				},
+				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+					return dynamicClient.Resource(clusterWorkspacesResource).Watch(options)
+				},
+			},
+		},
+	}
+	for _, p := range probes {
+		p.recorder = m
+		p.clock = clock
+		p.bookmarkTimeout = bookmarkTimeout
+		p.watchTimeout = watchTimeout
+		p.retryInterval = watchRetryInterval
+		p.sampler = &sampler{available: true}
+		m.AddSampler(p.sampler.ConditionWhenFailing(&Condition{
+			Level:   Warning,
+			Locator: p.locator,
+			Message: "watch is disrupted",
+		}))
+		go p.run(ctx)
+	}
+}
+
+// watchProbe keeps a watch open and records its disruptions: failing to establish
+// the watch, the watch closing before its timeout, the resource version expiring and
+// the watch delivering neither events nor bookmarks for longer than bookmarkTimeout,
+// if set.
+type watchProbe struct {
+	locator  string
+	lw       cache.ListerWatcher
+	recorder Recorder
+	// clock times the watches and the retries
+	clock clock.Clock
+
+	bookmarkTimeout time.Duration
+	watchTimeout    time.Duration
+	retryInterval   time.Duration
+
+	// sampler is unavailable while the watch is disrupted
+	sampler     *sampler
+	disruptedAt time.Time
+}
+
+func (p *watchProbe) run(ctx context.Context) {
+	var rv string
+	for ctx.Err() == nil {
+		if len(rv) == 0 {
+			list, err := p.lw.List(metav1.ListOptions{Limit: 1})
+			if err != nil {
+				p.disrupt(ctx, fmt.Sprintf("could not list: %v", err))
+				continue
+			}
+			listMeta, err := meta.ListAccessor(list)
+			if err != nil {
+				p.disrupt(ctx, fmt.Sprintf("could not read the list resource version: %v", err))
+				continue
+			}
+			rv = listMeta.GetResourceVersion()
+		}
+
+		timeoutSeconds := int64(p.watchTimeout / time.Second)
+		w, err := p.lw.Watch(metav1.ListOptions{
+			ResourceVersion:     rv,
+			AllowWatchBookmarks: true,
+			TimeoutSeconds:      &timeoutSeconds,
+		})
+		if err != nil {
+			if errors.IsResourceExpired(err) || errors.IsGone(err) {
+				rv = ""
+			}
+			p.disrupt(ctx, fmt.Sprintf("could not establish the watch: %v", err))
+			continue
+		}
+		if !p.disruptedAt.IsZero() {
+			p.recorder.Record(Condition{
+				Level:   Info,
+				Locator: p.locator,
+				Message: fmt.Sprintf("watch re-established after %s", p.clock.Since(p.disruptedAt).Round(time.Second)),
+			})
+			p.disruptedAt = time.Time{}
+			p.sampler.setAvailable(true)
+		}
+
+		var reason string
+		rv, reason = p.consume(ctx, w, rv)
+		if len(reason) > 0 {
+			p.disrupt(ctx, reason)
+		}
+	}
+}
+
+// consume reads the watch until it ends and returns the last resource version seen and,
+// if the watch ended unexpectedly, the reason.
+func (p *watchProbe) consume(ctx context.Context, w watch.Interface, rv string) (string, string) {
+	defer w.Stop()
+	started := p.clock.Now()
+	// the bookmark check is disabled without a timeout, a nil channel never fires
+	var (
+		timer    clock.Timer
+		timedOut <-chan time.Time
+	)
+	if p.bookmarkTimeout > 0 {
+		timer = p.clock.NewTimer(p.bookmarkTimeout)
+		defer func() { timer.Stop() }()
+		timedOut = timer.C()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return rv, ""
+		case <-timedOut:
+			return rv, fmt.Sprintf("watch delivered no events or bookmarks for %s", p.bookmarkTimeout)
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				// allow for the server closing the watch slightly early
+				if lasted := p.clock.Since(started); lasted < p.watchTimeout*9/10 {
+					return rv, fmt.Sprintf("watch closed unexpectedly after %s", lasted.Round(time.Second))
+				}
+				return rv, ""
+			}
+			if event.Type == watch.Error {
+				err := errors.FromObject(event.Object)
+				if errors.IsResourceExpired(err) || errors.IsGone(err) {
+					return "", fmt.Sprintf("watch expired: %v", err)
+				}
+				return rv, fmt.Sprintf("watch returned an error: %v", err)
+			}
+			if accessor, err := meta.Accessor(event.Object); err == nil && len(accessor.GetResourceVersion()) > 0 {
+				rv = accessor.GetResourceVersion()
+			}
+			if timer != nil {
+				// a new timer rather than Reset, which the fake clock ignores once stopped
+				timer.Stop()
+				timer = p.clock.NewTimer(p.bookmarkTimeout)
+				timedOut = timer.C()
+			}
+		}
+	}
+}
+
+// disrupt records the reason the watch was disrupted and waits before it is retried.
+func (p *watchProbe) disrupt(ctx context.Context, reason string) {
+	p.recorder.Record(Condition{
+		Level:   Warning,
+		Locator: p.locator,
+		Message: reason,
+	})
+	if p.disruptedAt.IsZero() {
+		p.disruptedAt = p.clock.Now()
+		p.sampler.setAvailable(false)
+	}
+	select {
+	case <-ctx.Done():
+	case <-p.clock.After(p.retryInterval):
+	}
+}
@@ -0,0 +1,163 @@
+package monitor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+func Test_watchProbe(t *testing.T) {
+	watches := make(chan *watch.FakeWatcher, 10)
+	var watchedRVs []string
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return &corev1.NamespaceList{ListMeta: metav1.ListMeta{ResourceVersion: "10"}}, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			watchedRVs = append(watchedRVs, options.ResourceVersion)
+			return <-watches, nil
+		},
+	}
+	m := NewMonitor()
+	fakeClock := clock.NewFakeClock(time.Now())
+	p := &watchProbe{
+		locator:         "watch/namespaces",
+		lw:              lw,
+		recorder:        m,
+		clock:           fakeClock,
+		bookmarkTimeout: time.Minute,
+		watchTimeout:    time.Hour,
+		retryInterval:   time.Second,
+		sampler:         &sampler{available: true},
+	}
+	messages := func() []string {
+		var messages []string
+		for _, event := range m.Events(time.Time{}, time.Time{}) {
+			messages = append(messages, event.Message)
+		}
+		return messages
+	}
+	// waitFor waits until the probe recorded n events and waits on the clock, for a
+	// retry or the bookmark timeout
+	waitFor := func(n int) {
+		t.Helper()
+		err := wait.PollImmediate(time.Millisecond, 30*time.Second, func() (bool, error) {
+			return len(messages()) >= n && fakeClock.HasWaiters(), nil
+		})
+		if err != nil {
+			t.Fatalf("expected %d events, got %q", n, messages())
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.run(ctx)
+	}()
+
+	// the watch closes early after an event
+	w := watch.NewFakeWithChanSize(1, false)
+	w.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "a", ResourceVersion: "11"}})
+	w.Stop()
+	watches <- w
+	waitFor(1)
+	fakeClock.Step(time.Second)
+
+	// the resource version expires
+	w = watch.NewFakeWithChanSize(1, false)
+	w.Error(&metav1.Status{Status: metav1.StatusFailure, Code: 410, Reason: metav1.StatusReasonExpired, Message: "too old resource version"})
+	watches <- w
+	waitFor(3)
+	fakeClock.Step(time.Second)
+
+	// the watch goes silent
+	watches <- watch.NewFake()
+	waitFor(4)
+	fakeClock.Step(time.Minute)
+	waitFor(5)
+	if p.sampler.isAvailable() {
+		t.Errorf("expected the watch to be disrupted")
+	}
+	watches <- watch.NewFake()
+	fakeClock.Step(time.Second)
+	waitFor(6)
+	cancel()
+	<-done
+
+	want := []string{
+		"watch closed unexpectedly after 0s",
+		"watch re-established after 1s",
+		"watch expired: too old resource version",
+		"watch re-established after 1s",
+		"watch delivered no events or bookmarks for 1m0s",
+		"watch re-established after 1s",
+	}
+	if got := messages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected events: %q", got)
+	}
+	if want := []string{"10", "11", "10", "10"}; !reflect.DeepEqual(watchedRVs, want) {
+		t.Errorf("unexpected watched resource versions: %v", watchedRVs)
+	}
+	if !p.sampler.isAvailable() {
+		t.Errorf("expected the watch to be available again")
+	}
+}
+
+func Test_watchProbe_consume(t *testing.T) {
+	namespace := func(rv string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "a", ResourceVersion: rv}}
+	}
+	tests := []struct {
+		name            string
+		bookmarkTimeout time.Duration
+		// step advances the clock once the watch delivered its events
+		step       time.Duration
+		wantReason string
+	}{
+		{
+			name:            "events reset the bookmark timeout",
+			bookmarkTimeout: time.Minute,
+			step:            50 * time.Second,
+			wantReason:      "watch closed unexpectedly after 1m40s",
+		},
+		{
+			name:       "bookmark check disabled",
+			step:       time.Hour,
+			wantReason: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClock := clock.NewFakeClock(time.Now())
+			p := &watchProbe{locator: "watch/namespaces", clock: fakeClock, bookmarkTimeout: tt.bookmarkTimeout, watchTimeout: time.Hour}
+			w := watch.NewFake()
+			type result struct{ rv, reason string }
+			done := make(chan result)
+			go func() {
+				rv, reason := p.consume(context.Background(), w, "10")
+				done <- result{rv, reason}
+			}()
+			// the fake watcher hands over every event, so the probe is waiting again
+			// once the next one is sent
+			w.Add(namespace("11"))
+			fakeClock.Step(tt.step)
+			w.Modify(namespace("12"))
+			fakeClock.Step(tt.step)
+			w.Modify(namespace("13"))
+			w.Stop()
+			got := <-done
+			if got.rv != "13" || got.reason != tt.wantReason {
+				t.Errorf("unexpected result %+v", got)
+			}
+		})
+	}
+}
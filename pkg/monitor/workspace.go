@@ -0,0 +1,137 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+)
+
+var clusterWorkspacesResource = schema.GroupVersionResource{Group: "tenancy.kcp.dev", Version: "v1alpha1", Resource: "clusterworkspaces"}
+
+const (
+	// logicalClusterAnnotation is set by kcp on every object to the logical cluster it is stored in
+	logicalClusterAnnotation = "kcp.dev/cluster"
+
+	workspacePhaseInitializing = "Initializing"
+)
+
+func startWorkspaceMonitoring(ctx context.Context, m Recorder, clock clock.Clock, client dynamic.Interface) {
+	workspaceInformer := cache.NewSharedIndexInformer(
+		NewErrorRecordingListWatcher(m, &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.Resource(clusterWorkspacesResource).List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.Resource(clusterWorkspacesResource).Watch(options)
+			},
+		}),
+		&unstructured.Unstructured{},
+		time.Hour,
+		nil,
+	)
+
+	m.AddSampler(func(now time.Time) []*Condition {
+		var conditions []*Condition
+		for _, obj := range workspaceInformer.GetStore().List() {
+			ws, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			if workspacePhase(ws) == workspacePhaseInitializing {
+				conditions = append(conditions, &Condition{
+					Level:   Warning,
+					Locator: locateWorkspace(ws),
+					Message: "workspace is initializing",
+				})
+			}
+		}
+		return conditions
+	})
+
+	startTime := clock.Now().Add(-time.Minute)
+	workspaceInformer.AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				ws, ok := obj.(*unstructured.Unstructured)
+				if !ok {
+					return
+				}
+				// filter out old workspaces so our monitor doesn't send a big chunk
+				// of workspace creations
+				if ws.GetCreationTimestamp().Time.Before(startTime) {
+					return
+				}
+				m.Record(Condition{
+					Level:   Info,
+					Locator: locateWorkspace(ws),
+					Message: "created",
+				})
+			},
+			DeleteFunc: func(obj interface{}) {
+				ws, ok := obj.(*unstructured.Unstructured)
+				if !ok {
+					return
+				}
+				m.Record(Condition{
+					Level:   Info,
+					Locator: locateWorkspace(ws),
+					Message: "deleted",
+				})
+			},
+			UpdateFunc: func(old, obj interface{}) {
+				ws, ok := obj.(*unstructured.Unstructured)
+				if !ok {
+					return
+				}
+				oldWS, ok := old.(*unstructured.Unstructured)
+				if !ok {
+					return
+				}
+				if ws.GetUID() != oldWS.GetUID() {
+					return
+				}
+				if phase, oldPhase := workspacePhase(ws), workspacePhase(oldWS); phase != oldPhase && len(oldPhase) > 0 {
+					m.Record(Condition{
+						Level:   Info,
+						Locator: locateWorkspace(ws),
+						Message: fmt.Sprintf("phase changed %s->%s", oldPhase, phase),
+					})
+				}
+			},
+		},
+	)
+
+	go workspaceInformer.Run(ctx.Done())
+}
+
+func workspacePhase(ws *unstructured.Unstructured) string {
+	phase, _, _ := unstructured.NestedString(ws.Object, "status", "phase")
+	return phase
+}
+
+// locateWorkspace returns the locator of a workspace including the path of the
+// logical cluster it lives in, for example "workspace/root:org:e2e-test-xxxxx".
+func locateWorkspace(ws *unstructured.Unstructured) string {
+	if cluster := logicalCluster(ws); len(cluster) > 0 {
+		return fmt.Sprintf("workspace/%s:%s", cluster, ws.GetName())
+	}
+	return fmt.Sprintf("workspace/%s", ws.GetName())
+}
+
+// logicalCluster returns the logical cluster an object is stored in, which kcp sets
+// on the objects returned through the wildcard logical cluster.
+func logicalCluster(obj metav1.Object) string {
+	if cluster := obj.GetAnnotations()[logicalClusterAnnotation]; len(cluster) > 0 {
+		return cluster
+	}
+	return obj.GetClusterName()
+}
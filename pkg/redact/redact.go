@@ -0,0 +1,116 @@
+// Package redact masks the credentials in the command lines and the output the
+// tests log, E.g. bearer tokens, OIDC tokens and client keys.
+package redact
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Mask replaces the redacted values.
+const Mask = "REDACTED"
+
+// secretFlags are the command line flags whose value is redacted, E.g. --token=xxx or --token xxx
+var secretFlags = []string{
+	"--token",
+	"--password",
+	"--client-key",
+	"--client-key-data",
+	"--client-certificate-data",
+	"--client-secret",
+	"--id-token",
+	"--refresh-token",
+}
+
+// rule replaces the matches of its pattern, keeping the first submatch if any, E.g. the
+// name of the field a token is the value of.
+type rule struct {
+	pattern *regexp.Regexp
+	keep    bool
+}
+
+var (
+	lock  sync.RWMutex
+	rules = []rule{
+		// PEM private keys
+		{pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+		// Authorization headers
+		{pattern: regexp.MustCompile(`(?i)(\bbearer\s+)[^\s"',]+`), keep: true},
+		// the secret flags, E.g. --token=xxx and --token xxx
+		{pattern: regexp.MustCompile(`((?:` + strings.Join(secretFlags, "|") + `)(?:=|\s+))[^\s"',]+`), keep: true},
+		// kubeconfig and JSON fields, E.g. token: xxx, "id-token":"xxx" or refresh-token=xxx of --auth-provider-arg
+		{pattern: regexp.MustCompile(`(?i)(\b(?:token|id-token|id_token|refresh-token|refresh_token|access_token|client-key-data|client-secret|client_secret|password)"?\s*[:=]\s*"?)[^\s"',}]+`), keep: true},
+		// JWTs, E.g. service account and OIDC tokens
+		{pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)},
+	}
+	loadExtraOnce sync.Once
+)
+
+// AddPattern redacts every match of the regular expression, in addition to the
+// built-in patterns. The E2E_TEST_REDACT_PATTERNS environment variable adds the
+// patterns it lists one per line, E.g. `my-api-key-[0-9a-f]+`, to every process.
+func AddPattern(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	rules = append(rules, rule{pattern: re})
+	return nil
+}
+
+// loadExtraPatterns adds the patterns of E2E_TEST_REDACT_PATTERNS, the invalid ones
+// are ignored so a typo never prints the secrets it meant to hide.
+func loadExtraPatterns() {
+	for _, pattern := range strings.Split(os.Getenv("E2E_TEST_REDACT_PATTERNS"), "\n") {
+		if pattern = strings.TrimSpace(pattern); len(pattern) > 0 {
+			AddPattern(pattern)
+		}
+	}
+}
+
+// String returns s with the credentials replaced by Mask.
+func String(s string) string {
+	loadExtraOnce.Do(loadExtraPatterns)
+	lock.RLock()
+	defer lock.RUnlock()
+	for _, r := range rules {
+		if !r.keep {
+			s = r.pattern.ReplaceAllLiteralString(s, Mask)
+			continue
+		}
+		s = r.pattern.ReplaceAllString(s, "${1}"+Mask)
+	}
+	return s
+}
+
+// Bytes returns b with the credentials replaced by Mask.
+func Bytes(b []byte) []byte {
+	return []byte(String(string(b)))
+}
+
+// Args returns the args of a command with the credentials replaced by Mask, including
+// the values of the secret flags given as a separate arg, E.g. --token xxx.
+func Args(args []string) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		if i > 0 && isSecretFlag(args[i-1]) {
+			redacted[i] = Mask
+			continue
+		}
+		redacted[i] = String(arg)
+	}
+	return redacted
+}
+
+func isSecretFlag(arg string) bool {
+	for _, flag := range secretFlags {
+		if arg == flag {
+			return true
+		}
+	}
+	return false
+}
@@ -32,6 +32,12 @@ type Options struct {
 
 	IncludeSuccessOutput bool
 
+	// MonitorConfig is passed to the monitor started for the run.
+	MonitorConfig monitor.Config
+	// DisruptionThresholds are the limits of the synthetic tests created from
+	// the monitor intervals after the run.
+	DisruptionThresholds DisruptionThresholds
+
 	Provider     string
 	SuiteOptions string
 
@@ -173,7 +179,7 @@ func (opt *Options) Run(args []string) error {
 
 	ctx, cancelFn := context.WithCancel(context.Background())
 	defer cancelFn()
-	abortCh := make(chan os.Signal)
+	abortCh := make(chan os.Signal, 1)
 	go func() {
 		<-abortCh
 		fmt.Fprintf(opt.ErrOut, "Interrupted, terminating tests\n")
@@ -189,7 +195,7 @@ func (opt *Options) Run(args []string) error {
 	}()
 	signal.Notify(abortCh, syscall.SIGINT, syscall.SIGTERM)
 
-	m, err := monitor.Start(ctx)
+	m, err := monitor.Start(ctx, opt.MonitorConfig)
 	if err != nil {
 		return err
 	}
@@ -279,6 +285,7 @@ func (opt *Options) Run(args []string) error {
 			opt.Out.Write(buf.Bytes())
 		}
 	}
+	syntheticTestResults = append(syntheticTestResults, createSyntheticTestsFromMonitor(m, opt.DisruptionThresholds, start, time.Now())...)
 
 	// attempt to retry failures to do flake detection
 	if fail > 0 && fail <= suite.MaximumAllowedFlakes {
@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/kcp-dev/kcp-tests/pkg/monitor"
 )
 
 // The below types are directly marshalled into XML. The types correspond to jUnit
@@ -139,6 +141,7 @@ func writeJUnitReport(filePrefix, name string, tests []*testCase, dir string, du
 			s.TestCases = append(s.TestCases, &JUnitTestCase{
 				Name:      test.name,
 				SystemOut: string(test.out),
+				SystemErr: formatEvents(test.events),
 				Duration:  test.duration.Seconds(),
 				FailureOutput: &FailureOutput{
 					Output: lastLinesUntil(string(test.out), 100, "fail ["),
@@ -171,6 +174,16 @@ func writeJUnitReport(filePrefix, name string, tests []*testCase, dir string, du
 	return ioutil.WriteFile(path, out, 0640)
 }
 
+// formatEvents returns one line per event.
+func formatEvents(events monitor.EventIntervals) string {
+	var buf strings.Builder
+	for _, event := range events {
+		buf.WriteString(event.String())
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
 func lastLinesUntil(output string, max int, until ...string) string {
 	output = strings.TrimSpace(output)
 	index := len(output) - 1
@@ -0,0 +1,100 @@
+package ginkgo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/kcp-dev/kcp-tests/pkg/monitor"
+)
+
+// testResourcePrefixes are the name prefixes of the workspaces and namespaces created by
+// the tests, see SetupWorkSpaceWithSpecificPath and WorkSpace.SetNamespace.
+var testResourcePrefixes = []string{"e2e-test-", "e2e-ns-"}
+
+// testResourcesEnv is the environment variable naming the file a test appends the names
+// of the workspaces and namespaces it creates to, one per line.
+const testResourcesEnv = "TEST_RESOURCES_FILE"
+
+// testResources are the names of the workspaces and namespaces created by one test.
+type testResources map[string]struct{}
+
+// newTestResourcesFile creates the empty file the test appends its resources to.
+func newTestResourcesFile() (string, error) {
+	f, err := ioutil.TempFile("", "kcp-tests-resources-")
+	if err != nil {
+		return "", fmt.Errorf("could not create the test resources file: %v", err)
+	}
+	return f.Name(), f.Close()
+}
+
+// readTestResources returns the resources a test recorded creating in the file at path.
+func readTestResources(path string) (testResources, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the test resources: %v", err)
+	}
+	resources := make(testResources)
+	for _, name := range strings.Fields(string(data)) {
+		resources[name] = struct{}{}
+	}
+	return resources, nil
+}
+
+// locatorResources returns the workspace and namespace names in a locator, for example
+// root, org and e2e-test-a in "workspace/root:org:e2e-test-a ns/e2e-ns-b pod/c".
+func locatorResources(locator string) []string {
+	var names []string
+	for _, field := range strings.Fields(locator) {
+		parts := strings.SplitN(field, "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch parts[0] {
+		case "workspace":
+			names = append(names, strings.Split(parts[1], ":")...)
+		case "ns":
+			names = append(names, parts[1])
+		}
+	}
+	return names
+}
+
+// isTestResource returns true if name is a workspace or namespace created by a test.
+func isTestResource(name string) bool {
+	for _, prefix := range testResourcePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// eventsForTest returns the events that concern the test owning resources: the events
+// located in one of its resources and the events not located in the resources of any
+// test, like the API becoming unavailable. The events of the resources of the tests
+// running in parallel are left out. Every event is returned if the resources of the
+// test are unknown.
+func eventsForTest(events monitor.EventIntervals, resources testResources) monitor.EventIntervals {
+	if resources == nil {
+		return events
+	}
+	var owned monitor.EventIntervals
+	for _, event := range events {
+		keep := true
+		for _, name := range locatorResources(event.Locator) {
+			if !isTestResource(name) {
+				continue
+			}
+			if _, ok := resources[name]; ok {
+				keep = true
+				break
+			}
+			keep = false
+		}
+		if keep {
+			owned = append(owned, event)
+		}
+	}
+	return owned
+}
@@ -0,0 +1,68 @@
+package ginkgo
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/kcp-tests/pkg/monitor"
+)
+
+func Test_eventsForTest(t *testing.T) {
+	path, err := newTestResourcesFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(path)
+	if resources, err := readTestResources(path); err != nil || len(resources) != 0 {
+		t.Fatalf("unexpected resources of a test that created none: %v: %v", resources, err)
+	}
+	if err := ioutil.WriteFile(path, []byte("e2e-test-kcp-workspace-abcde\ne2e-ns-kcp-workspace-fghij\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	resources, err := readTestResources(path)
+	if err != nil || len(resources) != 2 {
+		t.Fatalf("unexpected resources: %v: %v", resources, err)
+	}
+
+	var events monitor.EventIntervals
+	for _, locator := range []string{
+		"kube-apiserver",
+		"workspace/root:org:e2e-test-kcp-workspace-abcde",
+		"workspace/root:org:e2e-test-kcp-workspace-zzzzz",
+		"workspace/root:org:e2e-test-kcp-workspace-abcde ns/e2e-ns-kcp-workspace-fghij pod/web-0",
+		"ns/e2e-ns-kcp-workspace-zzzzz pod/web-0",
+		"ns/kube-system pod/etcd-0 node/node-a",
+		"workspace/root:org",
+	} {
+		events = append(events, &monitor.EventInterval{
+			From:      time.Now(),
+			To:        time.Now(),
+			Condition: &monitor.Condition{Level: monitor.Warning, Locator: locator, Message: "changed"},
+		})
+	}
+	var got []string
+	for _, event := range eventsForTest(events, resources) {
+		got = append(got, event.Locator)
+	}
+	want := []string{
+		"kube-apiserver",
+		"workspace/root:org:e2e-test-kcp-workspace-abcde",
+		"workspace/root:org:e2e-test-kcp-workspace-abcde ns/e2e-ns-kcp-workspace-fghij pod/web-0",
+		"ns/kube-system pod/etcd-0 node/node-a",
+		"workspace/root:org",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected events:\n%q", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+
+	if got := eventsForTest(events, nil); len(got) != len(events) {
+		t.Errorf("expected every event when the resources are unknown, got %d", len(got))
+	}
+}
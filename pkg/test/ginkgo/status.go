@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/kcp-dev/kcp-tests/pkg/monitor"
+	"github.com/kcp-dev/kcp-tests/pkg/redact"
 )
 
 type testStatus struct {
@@ -92,15 +93,16 @@ func (s *testStatus) Run(ctx context.Context, test *testCase) {
 		case test.failed:
 			s.out.Write(test.out)
 			fmt.Fprintln(s.out)
+			if s.monitor != nil {
+				// leave out the events of the workspaces and namespaces of the tests running in parallel
+				test.events = eventsForTest(s.monitor.Events(test.start, test.end), test.resources)
+			}
 			// only write the monitor output for a test if there is more than two tests being run (otherwise it's redundant)
-			if s.monitor != nil && s.total > 2 {
-				events := s.monitor.Events(test.start, test.end)
-				if len(events) > 0 {
-					for _, event := range events {
-						fmt.Fprintln(s.out, event.String())
-					}
-					fmt.Fprintln(s.out)
+			if s.total > 2 && len(test.events) > 0 {
+				for _, event := range test.events {
+					fmt.Fprintln(s.out, event.String())
 				}
+				fmt.Fprintln(s.out)
 			}
 			fmt.Fprintf(s.out, "failed: (%s) %s %q\n\n", test.duration, test.end.UTC().Format("2006-01-02T15:04:05"), test.name)
 			s.Failure()
@@ -110,16 +112,31 @@ func (s *testStatus) Run(ctx context.Context, test *testCase) {
 	test.start = time.Now()
 	c := exec.Command(os.Args[0], "run-test", test.name)
 	c.Env = append(os.Environ(), s.env...)
+	resourcesFile, err := newTestResourcesFile()
+	if err != nil {
+		fmt.Fprintf(s.out, "error: %v\n", err)
+	} else {
+		defer os.Remove(resourcesFile)
+		c.Env = append(c.Env, fmt.Sprintf("%s=%s", testResourcesEnv, resourcesFile))
+	}
 	s.Fprintf(fmt.Sprintf("started: (%s) %q\n\n", "%d/%d/%d", test.name))
 	out, err := runWithTimeout(ctx, c, s.timeout)
 	test.end = time.Now()
+	if len(resourcesFile) > 0 {
+		if resources, readErr := readTestResources(resourcesFile); readErr != nil {
+			fmt.Fprintf(s.out, "error: %v\n", readErr)
+		} else {
+			test.resources = resources
+		}
+	}
 
 	duration := test.end.Sub(test.start).Round(time.Second / 10)
 	if duration > time.Minute {
 		duration = duration.Round(time.Second)
 	}
 	test.duration = duration
-	test.out = out
+	// the output goes to the console and the JUnit results, mask what the test logged
+	test.out = redact.Bytes(out)
 	if err == nil {
 		test.success = true
 		return
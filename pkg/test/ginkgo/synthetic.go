@@ -0,0 +1,128 @@
+package ginkgo
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/kcp-tests/pkg/monitor"
+)
+
+// DisruptionThresholds are the limits of the synthetic tests created from the
+// monitor intervals after a run. A zero value selects the default.
+type DisruptionThresholds struct {
+	// APIUnavailable is the longest the kcp API may be unavailable at once.
+	APIUnavailable time.Duration
+	// WorkspaceInitializing is the longest a workspace may stay in the Initializing phase.
+	WorkspaceInitializing time.Duration
+}
+
+func (t DisruptionThresholds) withDefaults() DisruptionThresholds {
+	if t.APIUnavailable == 0 {
+		t.APIUnavailable = 10 * time.Second
+	}
+	if t.WorkspaceInitializing == 0 {
+		t.WorkspaceInitializing = 2 * time.Minute
+	}
+	return t
+}
+
+// syntheticTest turns the monitor intervals during which a condition held into a
+// test case that fails if any single interval lasted longer than max.
+type syntheticTest struct {
+	name string
+	max  time.Duration
+	// intervals selects the intervals the condition held during from the events
+	// of the run, intervals that have not ended are closed at end.
+	intervals func(events monitor.EventIntervals, end time.Time) monitor.EventIntervals
+}
+
+func newSyntheticTests(thresholds DisruptionThresholds) []syntheticTest {
+	thresholds = thresholds.withDefaults()
+	return []syntheticTest{
+		{
+			name:      fmt.Sprintf("kcp root workspace API should not be unavailable for more than %s", thresholds.APIUnavailable),
+			max:       thresholds.APIUnavailable,
+			intervals: transitionIntervals("kube-apiserver", "Kube API started failing", "Kube API started responding"),
+		},
+		{
+			name:      fmt.Sprintf("no workspace should stay Initializing for more than %s", thresholds.WorkspaceInitializing),
+			max:       thresholds.WorkspaceInitializing,
+			intervals: sampledIntervals("workspace/", "workspace is initializing"),
+		},
+	}
+}
+
+// transitionIntervals pairs the events on locator starting with the start message
+// with the next event on the same locator starting with the end message.
+func transitionIntervals(locator, start, end string) func(monitor.EventIntervals, time.Time) monitor.EventIntervals {
+	return func(events monitor.EventIntervals, runEnd time.Time) monitor.EventIntervals {
+		var intervals monitor.EventIntervals
+		var current *monitor.EventInterval
+		for _, event := range events {
+			if event.Locator != locator || !event.From.Equal(event.To) {
+				continue
+			}
+			switch {
+			case current == nil && strings.HasPrefix(event.Message, start):
+				current = &monitor.EventInterval{Condition: event.Condition, From: event.From}
+			case current != nil && strings.HasPrefix(event.Message, end):
+				current.To = event.From
+				intervals = append(intervals, current)
+				current = nil
+			}
+		}
+		if current != nil {
+			current.To = runEnd
+			intervals = append(intervals, current)
+		}
+		return intervals
+	}
+}
+
+// sampledIntervals selects the sampled conditions whose locator starts with the
+// locator prefix and whose message starts with message.
+func sampledIntervals(locatorPrefix, message string) func(monitor.EventIntervals, time.Time) monitor.EventIntervals {
+	return func(events monitor.EventIntervals, _ time.Time) monitor.EventIntervals {
+		var intervals monitor.EventIntervals
+		for _, event := range events {
+			if strings.HasPrefix(event.Locator, locatorPrefix) && strings.HasPrefix(event.Message, message) {
+				intervals = append(intervals, event)
+			}
+		}
+		return intervals
+	}
+}
+
+// createSyntheticTestsFromMonitor evaluates the synthetic tests against the events
+// recorded by the monitor between start and end.
+func createSyntheticTestsFromMonitor(m monitor.Interface, thresholds DisruptionThresholds, start, end time.Time) []*JUnitTestCase {
+	events := m.Events(start, end)
+	duration := end.Sub(start)
+
+	var results []*JUnitTestCase
+	for _, test := range newSyntheticTests(thresholds) {
+		result := &JUnitTestCase{
+			Name:     test.name,
+			Duration: duration.Seconds(),
+		}
+		buf, errBuf := &bytes.Buffer{}, &bytes.Buffer{}
+		failures := 0
+		for _, interval := range test.intervals(events, end) {
+			fmt.Fprintln(buf, interval.String())
+			if interval.To.Sub(interval.From) > test.max {
+				failures++
+				fmt.Fprintln(errBuf, interval.String())
+			}
+		}
+		result.SystemOut = buf.String()
+		if failures > 0 {
+			result.FailureOutput = &FailureOutput{
+				Output: fmt.Sprintf("%d intervals lasted longer than %s:\n\n%s", failures, test.max, errBuf.String()),
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,77 @@
+package ginkgo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/kcp-tests/pkg/monitor"
+)
+
+type fakeMonitor struct {
+	events monitor.EventIntervals
+}
+
+func (m *fakeMonitor) Events(from, to time.Time) monitor.EventIntervals     { return m.events }
+func (m *fakeMonitor) Conditions(from, to time.Time) monitor.EventIntervals { return nil }
+
+func Test_createSyntheticTestsFromMonitor(t *testing.T) {
+	at := func(s int) time.Time { return time.Unix(int64(s), 0) }
+	event := func(s int, locator, message string) *monitor.EventInterval {
+		return &monitor.EventInterval{Condition: &monitor.Condition{Locator: locator, Message: message}, From: at(s), To: at(s)}
+	}
+	tests := []struct {
+		name   string
+		events monitor.EventIntervals
+		failed []bool
+	}{
+		{
+			name:   "no events",
+			failed: []bool{false, false},
+		},
+		{
+			name: "short API outage",
+			events: monitor.EventIntervals{
+				event(10, "kube-apiserver", "Kube API started failing: connection refused"),
+				event(15, "kube-apiserver", "Kube API started responding to GET requests"),
+			},
+			failed: []bool{false, false},
+		},
+		{
+			name: "long API outage",
+			events: monitor.EventIntervals{
+				event(10, "kube-apiserver", "Kube API started failing: connection refused"),
+				event(15, "kube-apiserver", "failed contacting the API: connection refused"),
+				event(30, "kube-apiserver", "Kube API started responding to GET requests"),
+			},
+			failed: []bool{true, false},
+		},
+		{
+			name: "API outage until the end of the run",
+			events: monitor.EventIntervals{
+				event(80, "kube-apiserver", "Kube API started failing: connection refused"),
+			},
+			failed: []bool{true, false},
+		},
+		{
+			name: "workspace stuck initializing",
+			events: monitor.EventIntervals{
+				{Condition: &monitor.Condition{Locator: "workspace/root:e2e-test-a", Message: "workspace is initializing"}, From: at(0), To: at(30)},
+				{Condition: &monitor.Condition{Locator: "workspace/root:e2e-test-b", Message: "workspace is initializing"}, From: at(0), To: at(300)},
+			},
+			failed: []bool{false, true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := createSyntheticTestsFromMonitor(&fakeMonitor{events: tt.events}, DisruptionThresholds{}, at(0), at(100))
+			if len(results) != len(tt.failed) {
+				t.Fatalf("unexpected results: %#v", results)
+			}
+			for i, result := range results {
+				if failed := result.FailureOutput != nil; failed != tt.failed[i] {
+					t.Errorf("%q: expected failed=%t, got %#v", result.Name, tt.failed[i], result)
+				}
+			}
+		})
+	}
+}
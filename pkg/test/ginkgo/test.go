@@ -12,6 +12,8 @@ import (
 	"time"
 
 	"github.com/onsi/ginkgo/types"
+
+	"github.com/kcp-dev/kcp-tests/pkg/monitor"
 )
 
 type testCase struct {
@@ -29,6 +31,10 @@ type testCase struct {
 	success  bool
 	failed   bool
 	skipped  bool
+	// events are the monitor events of a failed test, see eventsForTest
+	events monitor.EventIntervals
+	// resources are the workspaces and namespaces the test recorded creating
+	resources testResources
 
 	previous *testCase
 }
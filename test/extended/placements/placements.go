@@ -75,10 +75,12 @@ var _ = g.Describe("[area/transparent-multi-cluster]", func() {
 		mySyncer := nsc.NewSyncTarget()
 		mySyncer.OutputFilePath = "/tmp/" + myWs.Name + "." + mySyncer.Name + ".yaml"
 		mySyncer.Create(k)
-		defer mySyncer.Clean(k)
+		k.DeferCleanup(func() error { return mySyncer.Clean(k) })
 
 		g.By("# Apply syncer resources on pcluster and wait for synctarget become ready")
-		defer k.AsPClusterKubeconf().WithoutNamespace().Run("delete").Args("-f", mySyncer.OutputFilePath).Execute()
+		k.DeferCleanup(func() error {
+			return k.AsPClusterKubeconf().WithoutNamespace().Run("delete").Args("-f", mySyncer.OutputFilePath).Execute()
+		})
 		err := k.AsPClusterKubeconf().WithoutNamespace().Run("apply").Args("-f", mySyncer.OutputFilePath).Execute()
 		o.Expect(err).ShouldNot(o.HaveOccurred())
 		mySyncer.WaitUntilReady(k)
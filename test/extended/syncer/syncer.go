@@ -6,6 +6,7 @@ import (
 
 	g "github.com/onsi/ginkgo"
 	o "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	exutil "github.com/kcp-dev/kcp-tests/test/extended/util"
 )
@@ -30,10 +31,12 @@ var _ = g.Describe("[area/transparent-multi-cluster]", func() {
 		mySyncer := NewSyncTarget()
 		mySyncer.OutputFilePath = "/tmp/" + myWs.Name + "." + mySyncer.Name + ".yaml"
 		mySyncer.Create(k)
-		defer mySyncer.Clean(k)
+		k.DeferCleanup(func() error { return mySyncer.Clean(k) })
 
 		g.By("# Apply syncer resources on pcluster and wait for synctarget become ready")
-		defer k.AsPClusterKubeconf().WithoutNamespace().WithoutWorkSpaceServer().Run("delete").Args("-f", mySyncer.OutputFilePath).Execute()
+		k.DeferCleanup(func() error {
+			return k.AsPClusterKubeconf().WithoutNamespace().WithoutWorkSpaceServer().Run("delete").Args("-f", mySyncer.OutputFilePath).Execute()
+		})
 		err := k.AsPClusterKubeconf().WithoutNamespace().WithoutWorkSpaceServer().Run("apply").Args("-f", mySyncer.OutputFilePath).Execute()
 		o.Expect(err).ShouldNot(o.HaveOccurred())
 		mySyncer.WaitUntilReadyAndDeploymentsAPISynced(k)
@@ -41,8 +44,8 @@ var _ = g.Describe("[area/transparent-multi-cluster]", func() {
 
 		g.By("# Creating workload using the BYO compute should work well")
 		myDeployment := exutil.NewDeployment(exutil.SetDeploymentNameSpace(myWs.CurrentNameSpace))
-		defer myDeployment.Clean(k)
 		myDeployment.Create(k)
+		k.TrackCreated(schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, myDeployment.Namespace, myDeployment.Name, exutil.InWorkSpace)
 		myDeployment.WaitUntilReady(k)
 		myDeployment.CheckDisplayColumns(k)
 
@@ -87,10 +90,12 @@ var _ = g.Describe("[area/transparent-multi-cluster]", func() {
 		mySyncer := NewSyncTarget()
 		mySyncer.OutputFilePath = "/tmp/" + myWs.Name + "." + mySyncer.Name + ".yaml"
 		mySyncer.Create(k)
-		defer mySyncer.Clean(k)
+		k.DeferCleanup(func() error { return mySyncer.Clean(k) })
 
 		g.By("# Apply syncer resources on pcluster and wait for synctarget become ready")
-		defer k.AsPClusterKubeconf().WithoutNamespace().WithoutWorkSpaceServer().Run("delete").Args("-f", mySyncer.OutputFilePath).Execute()
+		k.DeferCleanup(func() error {
+			return k.AsPClusterKubeconf().WithoutNamespace().WithoutWorkSpaceServer().Run("delete").Args("-f", mySyncer.OutputFilePath).Execute()
+		})
 		err := k.AsPClusterKubeconf().WithoutNamespace().WithoutWorkSpaceServer().Run("apply").Args("-f", mySyncer.OutputFilePath).Execute()
 		o.Expect(err).ShouldNot(o.HaveOccurred())
 		mySyncer.WaitUntilReadyAndDeploymentsAPISynced(k)
@@ -98,8 +103,8 @@ var _ = g.Describe("[area/transparent-multi-cluster]", func() {
 
 		g.By("# Creating workload using the BYO compute should work well")
 		myDeployment := exutil.NewDeployment(exutil.SetDeploymentNameSpace(myWs.CurrentNameSpace))
-		defer myDeployment.Clean(k)
 		myDeployment.Create(k)
+		k.TrackCreated(schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, myDeployment.Namespace, myDeployment.Name, exutil.InWorkSpace)
 		myDeployment.WaitUntilReady(k)
 		myDeployment.CheckDisplayColumns(k)
 
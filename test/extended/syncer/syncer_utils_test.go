@@ -0,0 +1,93 @@
+package syncer
+
+import (
+	"strings"
+	"testing"
+
+	o "github.com/onsi/gomega"
+
+	exutil "github.com/kcp-dev/kcp-tests/test/extended/util"
+)
+
+// assertionFailed is the panic of a failed gomega assertion, see assertionFailure.
+type assertionFailed string
+
+func init() {
+	o.RegisterFailHandler(func(message string, _ ...int) {
+		panic(assertionFailed(message))
+	})
+}
+
+// assertionFailure runs fn and returns the message of the gomega assertion that failed
+// in it, or an empty string.
+func assertionFailure(fn func()) (message string) {
+	defer func() {
+		if r := recover(); r != nil {
+			failed, ok := r.(assertionFailed)
+			if !ok {
+				panic(r)
+			}
+			message = string(failed)
+		}
+	}()
+	fn()
+	return ""
+}
+
+func TestSyncTarget_CheckDisplayColumns(t *testing.T) {
+	const (
+		server = "--server=https://kcp.example.com/clusters/root:org:ws"
+		output = "NAME     AGE\nsyncer   5m\n"
+		wide   = "NAME     LOCATION   READY   SYNCED API RESOURCES   KEY       AGE\nsyncer   syncer     True    [\"deployments.apps\"]   2lsr8hu   5m\n"
+	)
+	tests := []struct {
+		name          string
+		script        func(fake *exutil.FakeExecutor)
+		wantAssertion string
+	}{
+		{
+			name: "expected columns",
+			script: func(fake *exutil.FakeExecutor) {
+				fake.Expect("get", server, "synctarget", "syncer").Returns(output)
+				fake.Expect("get", server, "synctarget", "syncer", "-o", "wide").Returns(wide)
+			},
+		},
+		{
+			name: "missing column",
+			script: func(fake *exutil.FakeExecutor) {
+				fake.Expect("get", server, "synctarget", "syncer").Returns("NAME\nsyncer\n")
+			},
+			wantAssertion: `to contain substring`,
+		},
+		{
+			name: "missing wide column",
+			script: func(fake *exutil.FakeExecutor) {
+				fake.Expect("get", server, "synctarget", "syncer").Returns(output)
+				fake.Expect("get", server, "synctarget", "syncer", "-o", "wide").Returns(strings.Replace(wide, "KEY ", "    ", 1))
+			},
+			wantAssertion: `to contain substring`,
+		},
+		{
+			name: "failed",
+			script: func(fake *exutil.FakeExecutor) {
+				fake.Expect("get", server, "synctarget", "syncer").Fails(1, `Error from server (NotFound): synctargets.workload.kcp.dev "syncer" not found`)
+			},
+			wantAssertion: "exit status 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &exutil.FakeExecutor{}
+			tt.script(fake)
+			syncTarget := &SyncTarget{Name: "syncer", WorkSpaceServer: "https://kcp.example.com/clusters/root:org:ws"}
+
+			failure := assertionFailure(func() { syncTarget.CheckDisplayColumns(exutil.NewCLIWithExecutor(fake)) })
+			if tt.wantAssertion == "" && failure != "" {
+				t.Fatalf("unexpected assertion failure %q", failure)
+			}
+			if !strings.Contains(failure, tt.wantAssertion) {
+				t.Errorf("expected an assertion failure with %q, got %q", tt.wantAssertion, failure)
+			}
+		})
+	}
+}
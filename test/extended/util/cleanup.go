@@ -0,0 +1,152 @@
+package util
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// CleanupTarget is the cluster a tracked object is deleted from, see TrackCreated.
+type CleanupTarget string
+
+const (
+	// InWorkSpace deletes the object from the current workspace of the CLI when it is tracked
+	InWorkSpace CleanupTarget = "workspace"
+	// InPCluster deletes the object from the pcluster, see SetPClusterKubeconf
+	InPCluster CleanupTarget = "pcluster"
+)
+
+// cleanup is a function registered with DeferCleanup.
+type cleanup struct {
+	description string
+	fn          func() error
+}
+
+// cleanupStack holds the cleanups of a test, shared by the copies of the CLI, E.g. the
+// one of WithoutNamespace, so a cleanup registered on any of them runs.
+type cleanupStack struct {
+	lock     sync.Mutex
+	cleanups []cleanup
+}
+
+func (s *cleanupStack) push(description string, fn func() error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.cleanups = append(s.cleanups, cleanup{description: description, fn: fn})
+}
+
+// pop removes and returns the cleanups, last registered first.
+func (s *cleanupStack) pop() []cleanup {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	cleanups := make([]cleanup, 0, len(s.cleanups))
+	for i := len(s.cleanups) - 1; i >= 0; i-- {
+		cleanups = append(cleanups, s.cleanups[i])
+	}
+	s.cleanups = nil
+	return cleanups
+}
+
+// DeferCleanup registers fn to run when the test is torn down, even if it failed.
+// The cleanups run last registered first, an error or a panic of one does not stop
+// the others and fails the test once it is torn down, listing what was left over. E.g.
+//
+//	k.DeferCleanup(func() error {
+//		return k.AsPClusterKubeconf().WithoutNamespace().Run("delete").Args("-f", manifest).Execute()
+//	})
+func (c *CLI) DeferCleanup(fn func() error) {
+	description := "cleanup"
+	if _, file, line, ok := runtime.Caller(1); ok {
+		description = fmt.Sprintf("cleanup registered at %s:%d", filepath.Base(file), line)
+	}
+	c.deferCleanup(description, fn)
+}
+
+func (c *CLI) deferCleanup(description string, fn func() error) {
+	if c.cleanups == nil {
+		c.cleanups = &cleanupStack{}
+	}
+	c.cleanups.push(description, fn)
+}
+
+// TrackCreated registers the deletion of an object created by the test from the
+// target cluster, see DeferCleanup. An object already deleted is not an error. E.g.
+//
+//	k.TrackCreated(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, "", ns, exutil.InPCluster)
+func (c *CLI) TrackCreated(resource schema.GroupVersionResource, namespace, name string, target CleanupTarget) {
+	description := fmt.Sprintf("%s %q", resource.Resource, name)
+	if namespace != "" {
+		description += fmt.Sprintf(" in namespace %q", namespace)
+	}
+
+	// the client is built now, the current workspace or the kubeconfig may change later
+	var (
+		config   *rest.Config
+		setupErr error
+	)
+	switch target {
+	case InWorkSpace:
+		description += fmt.Sprintf(" of workspace %q", c.currentWorkSpace.Name)
+		config, setupErr = c.logicalClusterConfig(c.currentWorkSpace.ServerURL)
+	case InPCluster:
+		description += " of the pcluster"
+		if c.pClusterConfigPath == "" {
+			setupErr = fmt.Errorf("no pcluster kubeconfig, please use SetPClusterKubeconf to set it firstly")
+		} else {
+			config, setupErr = getClientConfig(c.pClusterConfigPath)
+		}
+	default:
+		setupErr = fmt.Errorf("unknown cleanup target %q", target)
+	}
+	var client dynamic.Interface
+	if setupErr == nil {
+		client, setupErr = dynamic.NewForConfig(config)
+	}
+
+	c.deferCleanup("deleting "+description, func() error {
+		if setupErr != nil {
+			return setupErr
+		}
+		err := client.Resource(resource).Namespace(namespace).Delete(name, &metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	})
+}
+
+// runCleanups runs the cleanups of the test and returns an error listing the ones
+// that failed.
+func (c *CLI) runCleanups() error {
+	if c.cleanups == nil {
+		return nil
+	}
+	var failures []string
+	for _, cleanup := range c.cleanups.pop() {
+		if err := runCleanup(cleanup.fn); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", cleanup.description, err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("could not clean up everything the test created, please check for leftovers:\n- %s", strings.Join(failures, "\n- "))
+	}
+	return nil
+}
+
+// runCleanup runs fn, turning a panic, E.g. of a failed assertion, into an error.
+func runCleanup(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn()
+}
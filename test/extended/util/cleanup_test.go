@@ -0,0 +1,127 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestCLI_runCleanups(t *testing.T) {
+	k := NewCLIWithExecutor(&FakeExecutor{})
+	var ran []string
+	k.DeferCleanup(func() error {
+		ran = append(ran, "first")
+		return nil
+	})
+	k.DeferCleanup(func() error {
+		ran = append(ran, "second")
+		return errors.New("namespace is terminating")
+	})
+	// a copy of the CLI shares the cleanups
+	k.WithoutNamespace().DeferCleanup(func() error {
+		ran = append(ran, "third")
+		panic("assertion failed")
+	})
+	k.DeferCleanup(func() error {
+		ran = append(ran, "fourth")
+		return nil
+	})
+
+	err := k.runCleanups()
+	if want := []string{"fourth", "third", "second", "first"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("expected the cleanups to run last registered first, got %v", ran)
+	}
+	if err == nil {
+		t.Fatal("expected the failed cleanups to be reported")
+	}
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != 3 ||
+		!strings.HasPrefix(lines[1], "- cleanup registered at cleanup_test.go:") || !strings.HasSuffix(lines[1], ": panic: assertion failed") ||
+		!strings.HasPrefix(lines[2], "- cleanup registered at cleanup_test.go:") || !strings.HasSuffix(lines[2], ": namespace is terminating") {
+		t.Errorf("unexpected error:\n%v", err)
+	}
+
+	ran = nil
+	if err := k.runCleanups(); err != nil || len(ran) > 0 {
+		t.Errorf("expected the cleanups to run once, got %v: %v", ran, err)
+	}
+}
+
+func TestCLI_TrackCreated(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		requests []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		lock.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/deleted") {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`)
+			return
+		}
+		fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Success"}`)
+	}))
+	defer server.Close()
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := ioutil.WriteFile(kubeconfig, []byte(strings.Replace(testKubeconfig, "https://kcp-unstable.example.com:6443/clusters/root", server.URL, 1)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	k := NewCLIWithExecutor(&FakeExecutor{})
+	k.adminConfigPath = kubeconfig
+	k.currentWorkSpace = &WorkSpace{Name: "ws", ServerURL: server.URL + "/clusters/root:org:ws"}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	k.TrackCreated(deployments, "default", "web", InWorkSpace)
+	k.TrackCreated(deployments, "default", "deleted", InWorkSpace)
+	k.TrackCreated(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, "", "syncer", InPCluster)
+	// the workspace of an object is the current one when it is tracked
+	k.currentWorkSpace = &WorkSpace{Name: "other", ServerURL: server.URL + "/clusters/root:org:other"}
+
+	err := k.runCleanups()
+	want := []string{
+		"DELETE /clusters/root:org:ws/apis/apps/v1/namespaces/default/deployments/deleted",
+		"DELETE /clusters/root:org:ws/apis/apps/v1/namespaces/default/deployments/web",
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+	// the object already deleted is not a failure, the one of the pcluster without a
+	// pcluster kubeconfig is
+	if err == nil || strings.Count(err.Error(), "\n- ") != 1 || !strings.Contains(err.Error(), `- deleting namespaces "syncer" of the pcluster: no pcluster kubeconfig`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCLI_TeardownWorkSpace_leftovers(t *testing.T) {
+	t.Setenv("DELETE_WORKSPACE", "")
+	fake := &FakeExecutor{}
+	fake.Expect("delete", "...")
+	k := NewCLIWithExecutor(fake)
+	k.workSpacesToDelete = []*WorkSpace{{Name: "ws", ParentServerURL: "https://kcp.example.com/clusters/root:org"}}
+	k.DeferCleanup(func() error { return errors.New(`namespaces "kcp-syncer-a" is forbidden`) })
+
+	failure := assertionFailure(k.TeardownWorkSpace)
+	if !strings.Contains(failure, "please check for leftovers:") || !strings.Contains(failure, "- cleanup registered at cleanup_test.go:") || !strings.Contains(failure, `namespaces "kcp-syncer-a" is forbidden`) {
+		t.Errorf("expected the test to fail with the leftovers, got %q", failure)
+	}
+	// the workspaces are deleted before the test fails
+	if len(fake.Invocations) != 1 || fake.Invocations[0][0] != "delete" {
+		t.Errorf("unexpected invocations: %q", fake.Invocations)
+	}
+
+	if failure := assertionFailure(k.TeardownWorkSpace); len(failure) > 0 {
+		t.Errorf("expected no failure without leftovers, got %q", failure)
+	}
+}
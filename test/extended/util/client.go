@@ -26,6 +26,7 @@ import (
 	"k8s.io/client-go/dynamic"
 	"sigs.k8s.io/yaml"
 
+	"github.com/kcp-dev/kcp-tests/pkg/redact"
 	g "github.com/onsi/ginkgo"
 	o "github.com/onsi/gomega"
 	"github.com/pborman/uuid"
@@ -42,6 +43,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/apiserver/pkg/storage/names"
+	"k8s.io/client-go/discovery"
 	memory "k8s.io/client-go/discovery/cached"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -78,6 +80,7 @@ type CLI struct {
 	configPath             string
 	currentWorkSpace       *WorkSpace
 	pClusterConfigPath     string
+	workSpaceConfigPath    string
 	adminConfigPath        string
 	orgServerURL           string // User organization workspace server url
 	homeServerURL          string // User homes workspace server url
@@ -94,8 +97,13 @@ type CLI struct {
 	withoutKubeconf        bool
 	withoutWorkSpaceServer bool
 	asPClusterKubeconf     bool
+	retry                  *RetryPolicy
+	timeout                *time.Duration
+	ctx                    context.Context
+	executor               Executor
 	kubeFramework          *e2e.Framework
 	resourcesToDelete      []resourceRef
+	cleanups               *cleanupStack
 }
 
 type resourceRef struct {
@@ -146,14 +154,20 @@ func loadConfig() {
 		}
 		rootServer = u.Scheme + "://" + u.Host + "/clusters/root"
 	}
-	orgServer, err = client.Run("get").Args("workspace", "--server="+rootServer, `-o=jsonpath={.items[?(@.spec.type.name=='organization')].status.URL}`).Output()
+	// the output of a failed command is its error, not a server
+	orgServer, homeServer = "", ""
+	output, err := client.Run("get").Args("workspace", "--server="+rootServer, `-o=jsonpath={.items[?(@.spec.type.name=='organization')].status.URL}`).Output()
 	if err != nil {
 		e2e.Logf(`Getting organization workspace server failed: "%v"`, err)
+	} else {
+		orgServer = output
 	}
 	e2e.Debugf(`User organization workspace server is: "%s"`, orgServer)
-	homeServer, err = client.Run("get").Args("workspace/~", "--server="+rootServer, "-o=jsonpath={.status.URL}").Output()
+	output, err = client.Run("get").Args("workspace/~", "--server="+rootServer, "-o=jsonpath={.status.URL}").Output()
 	if err != nil {
 		e2e.Logf(`Getting home workspace server failed: "%v"`, err)
+	} else {
+		homeServer = output
 	}
 	e2e.Debugf(`User home workspace server is: "%s"`, homeServer)
 }
@@ -188,6 +202,7 @@ func NewCLI(project, adminConfigPath string) *CLI {
 
 	client.kubeFramework = e2e.NewDefaultFramework(project)
 	client.kubeFramework.SkipNamespaceCreation = true
+	client.cleanups = &cleanupStack{}
 	client.username = "admin"
 	client.execPath = "kubectl"
 	client.showInfo = true
@@ -209,6 +224,7 @@ func NewCLIWithWorkSpace(wsPrefix string) *CLI {
 	g.AfterEach(client.TeardownWorkSpace)
 	client.kubeFramework = e2e.NewDefaultFramework(wsPrefix)
 	client.kubeFramework.SkipNamespaceCreation = true
+	client.cleanups = &cleanupStack{}
 	client.execPath = "kubectl"
 	client.adminConfigPath = KubeConfigPath()
 	client.showInfo = true
@@ -325,6 +341,7 @@ func (c *CLI) SetupProject() {
 	e2e.Logf("The user is now %q", c.Username())
 
 	e2e.Logf("Creating project %q", newNamespace)
+	recordTestResource(newNamespace)
 	_, err := c.ProjectClient().ProjectV1().ProjectRequests().Create(&projectv1.ProjectRequest{
 		ObjectMeta: metav1.ObjectMeta{Name: newNamespace},
 	})
@@ -402,6 +419,7 @@ func (c *CLI) SetupProject() {
 func (c *CLI) CreateProject() string {
 	newNamespace := names.SimpleNameGenerator.GenerateName(fmt.Sprintf("e2e-test-%s-", c.kubeFramework.BaseName))
 	e2e.Logf("Creating project %q", newNamespace)
+	recordTestResource(newNamespace)
 	_, err := c.ProjectClient().ProjectV1().ProjectRequests().Create(&projectv1.ProjectRequest{
 		ObjectMeta: metav1.ObjectMeta{Name: newNamespace},
 	})
@@ -430,6 +448,7 @@ func (c *CLI) TeardownProject() {
 	if len(c.Namespace()) > 0 && g.CurrentGinkgoTestDescription().Failed && e2e.TestContext.DumpLogsOnFailure {
 		e2e.DumpAllNamespaceInfo(c.kubeFramework.ClientSet, c.Namespace())
 	}
+	cleanupErr := c.runCleanups()
 
 	if len(c.configPath) > 0 {
 		os.Remove(c.configPath)
@@ -440,6 +459,8 @@ func (c *CLI) TeardownProject() {
 		err := dynamicClient.Resource(resource.Resource).Namespace(resource.Namespace).Delete(resource.Name, nil)
 		e2e.Logf("Deleted %v, err: %v", resource, err)
 	}
+	// the test fails with the leftovers once the rest of the teardown ran
+	o.Expect(cleanupErr).NotTo(o.HaveOccurred())
 }
 
 // SetupWorkSpace creates a new WorkSpace under the org workspace
@@ -453,17 +474,9 @@ func (c *CLI) SetupWorkSpaceWithNamespace() {
 	c.currentWorkSpace.SetNamespace(c)
 }
 
-// SetupWorkSpaceWithSpecificPath creates a new WorkSpace with specific paths
-func (c *CLI) SetupWorkSpaceWithSpecificPath(serverURL string) {
-	newWorkSpace := names.SimpleNameGenerator.GenerateName(fmt.Sprintf("e2e-test-%s-", c.kubeFramework.BaseName))
-	e2e.Logf("Creating workspace %q", newWorkSpace)
-	output, errinfo := c.WithoutKubeconf().WithoutWorkSpaceServer().Run("ws").Args("create", "--server="+serverURL, newWorkSpace).Output()
-	o.Expect(errinfo).NotTo(o.HaveOccurred())
-	o.Expect(output).Should(o.ContainSubstring("is ready to use"))
-	c.currentWorkSpace = &WorkSpace{Name: newWorkSpace, ParentServerURL: serverURL, ServerURL: serverURL + ":" + newWorkSpace}
-	// Add the workspace to teardown deleted list
-	c.workSpacesToDelete = append(c.workSpacesToDelete, c.currentWorkSpace)
-	e2e.Logf("Workspace %q has been fully provisioned.", c.currentWorkSpace.Name)
+// SetupWorkSpaceWithSpecificPath creates a new WorkSpace with specific paths, see SetupWorkSpaceWithOptions
+func (c *CLI) SetupWorkSpaceWithSpecificPath(serverURL string, opts ...WorkSpaceOption) {
+	c.SetupWorkSpaceWithOptions(append([]WorkSpaceOption{SetWorkSpaceParent(serverURL)}, opts...)...)
 }
 
 // ListWorkSpacesWithSpecificPath returns a list of WorkSpaces under a specific workspace
@@ -478,9 +491,12 @@ func (c *CLI) ListWorkSpacesWithSpecificPath(serverURL string) []string {
 
 // TeardownWorkSpace removes workspaces created by this test.
 func (c *CLI) TeardownWorkSpace() {
+	// the cleanups run first, they may need the workspaces and the test kubeconfig
+	cleanupErr := c.runCleanups()
 	if len(c.configPath) > 0 {
 		os.Remove(c.configPath)
 	}
+	c.removeWorkSpaceKubeconfig()
 	if !(os.Getenv("DELETE_WORKSPACE") == "false") {
 		// Sort the need to delete workSpaces delete the deepest level firstly
 		sort.Slice(c.workSpacesToDelete, func(i, j int) bool {
@@ -500,6 +516,8 @@ func (c *CLI) TeardownWorkSpace() {
 			e2e.Logf("Deleted %v, err: %v", ws.Name, err)
 		}
 	}
+	// the test fails with the leftovers once the workspaces are deleted
+	o.Expect(cleanupErr).NotTo(o.HaveOccurred())
 }
 
 // Verbose turns on printing verbose messages when executing OpenShift commands
@@ -663,6 +681,87 @@ func (c *CLI) AdminConfig() *rest.Config {
 	return clientConfig
 }
 
+// WorkSpaceConfig returns a client configuration for the current workspace with the
+// credentials of the kubeconfig the commands are run with.
+func (c *CLI) WorkSpaceConfig() *rest.Config {
+	return c.LogicalClusterConfig(c.currentWorkSpace.ServerURL)
+}
+
+// WorkSpaceKubeClient provides a Kubernetes client for the current workspace.
+func (c *CLI) WorkSpaceKubeClient() kubernetes.Interface {
+	return kubernetes.NewForConfigOrDie(c.WorkSpaceConfig())
+}
+
+// WorkSpaceDynamicClient provides a dynamic client for the current workspace.
+func (c *CLI) WorkSpaceDynamicClient() dynamic.Interface {
+	return dynamic.NewForConfigOrDie(c.WorkSpaceConfig())
+}
+
+// WorkSpaceDiscoveryClient provides a discovery client for the current workspace.
+func (c *CLI) WorkSpaceDiscoveryClient() discovery.DiscoveryInterface {
+	return discovery.NewDiscoveryClientForConfigOrDie(c.WorkSpaceConfig())
+}
+
+// LogicalClusterConfig returns a client configuration for a logical cluster with the
+// credentials of the kubeconfig the commands are run with. The cluster is either a
+// workspace server URL or a logical cluster path, E.g. root:orgID:e2e-test-kcp-workspace-xxxxx
+func (c *CLI) LogicalClusterConfig(cluster string) *rest.Config {
+	clientConfig, err := c.logicalClusterConfig(cluster)
+	o.Expect(err).NotTo(o.HaveOccurred())
+	return clientConfig
+}
+
+func (c *CLI) logicalClusterConfig(cluster string) (*rest.Config, error) {
+	configPath := c.configPath
+	if configPath == "" {
+		configPath = c.workSpaceConfigPath
+	}
+	if configPath == "" {
+		configPath = c.adminConfigPath
+	}
+	clientConfig, err := getClientConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	host, err := logicalClusterServerURL(clientConfig.Host, cluster)
+	if err != nil {
+		return nil, err
+	}
+	clientConfig.Host = host
+	return clientConfig, nil
+}
+
+// LogicalClusterDynamicClient provides a dynamic client for a logical cluster, see LogicalClusterConfig.
+func (c *CLI) LogicalClusterDynamicClient(cluster string) dynamic.Interface {
+	return dynamic.NewForConfigOrDie(c.LogicalClusterConfig(cluster))
+}
+
+// LogicalClusterDiscoveryClient provides a discovery client for a logical cluster, see LogicalClusterConfig.
+func (c *CLI) LogicalClusterDiscoveryClient(cluster string) discovery.DiscoveryInterface {
+	return discovery.NewDiscoveryClientForConfigOrDie(c.LogicalClusterConfig(cluster))
+}
+
+// logicalClusterServerURL returns the server URL of a logical cluster, which is either
+// a server URL already or a logical cluster path served by the server of host.
+func logicalClusterServerURL(host, cluster string) (string, error) {
+	if strings.Contains(cluster, "://") {
+		return cluster, nil
+	}
+	if cluster == "" {
+		return "", fmt.Errorf("no logical cluster to connect to")
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse server URL %q: %v", host, err)
+	}
+	// the server may be served under a base path, E.g. behind a front proxy
+	if i := strings.Index(u.Path, "/clusters/"); i >= 0 {
+		u.Path = u.Path[:i]
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/clusters/" + cluster
+	return u.String(), nil
+}
+
 // Namespace returns the name of the namespace used in the current test case.
 // If the namespace is not set, an empty string is returned.
 func (c *CLI) Namespace() string {
@@ -711,14 +810,20 @@ func (c *CLI) setOutput(out io.Writer) *CLI {
 func (c *CLI) Run(command string, flags ...string) *CLI {
 	in, out, errout := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
 	nc := &CLI{
-		execPath:           c.execPath,
-		command:            command,
-		kubeFramework:      c.KubeFramework(),
-		adminConfigPath:    c.adminConfigPath,
-		configPath:         c.configPath,
-		pClusterConfigPath: c.pClusterConfigPath,
-		username:           c.username,
-		flags:              flags,
+		execPath:            c.execPath,
+		command:             command,
+		kubeFramework:       c.KubeFramework(),
+		adminConfigPath:     c.adminConfigPath,
+		configPath:          c.configPath,
+		pClusterConfigPath:  c.pClusterConfigPath,
+		workSpaceConfigPath: c.workSpaceConfigPath,
+		username:            c.username,
+		flags:               flags,
+		retry:               c.retry,
+		timeout:             c.timeout,
+		ctx:                 c.ctx,
+		cleanups:            c.cleanups,
+		executor:            c.executor,
 	}
 	if !c.withoutKubeconf {
 		if c.asPClusterKubeconf {
@@ -729,6 +834,8 @@ func (c *CLI) Run(command string, flags ...string) *CLI {
 			}
 		} else if c.configPath != "" {
 			nc.flags = append(nc.flags, fmt.Sprintf("--kubeconfig=%s", c.configPath))
+		} else if c.workSpaceConfigPath != "" {
+			nc.flags = append(nc.flags, fmt.Sprintf("--kubeconfig=%s", c.workSpaceConfigPath))
 		}
 	}
 	if c.asPClusterKubeconf && !c.withoutNamespace {
@@ -744,11 +851,15 @@ func (c *CLI) Run(command string, flags ...string) *CLI {
 		}
 	}
 
-	// TODO: Temp solution for parallel executing our test cases
-	// When https://github.com/kcp-dev/kcp/issues/1689 finished
-	// We could make it simply and just use the --kubeconfig instead of --server.
-	if !c.withoutWorkSpaceServer && !c.asPClusterKubeconf {
-		nc.flags = append(nc.flags, "--server="+c.currentWorkSpace.ServerURL)
+	// The workspaces created by the test have a context in the test kubeconfig, the
+	// others, E.g. the org workspace, are still reached with --server. The config
+	// commands, E.g. "config use-context", work on the kubeconfig itself.
+	if !c.withoutWorkSpaceServer && !c.asPClusterKubeconf && command != "config" {
+		if c.usesWorkSpaceContext() {
+			nc.flags = append(nc.flags, "--context="+c.currentWorkSpace.Context)
+		} else {
+			nc.flags = append(nc.flags, "--server="+c.currentWorkSpace.ServerURL)
+		}
 	}
 	nc.stdin, nc.stdout, nc.stderr = in, out, errout
 	return nc.setOutput(c.stdout)
@@ -779,7 +890,7 @@ func (c *CLI) Args(args ...string) *CLI {
 
 func (c *CLI) printCmd() string {
 	finalArgs := c.assembleArgs()
-	return strings.Join(finalArgs, " ")
+	return strings.Join(redact.Args(finalArgs), " ")
 }
 
 // ExitError struct
@@ -787,6 +898,29 @@ type ExitError struct {
 	Cmd    string
 	StdErr string
 	*exec.ExitError
+	// code is the exit code of a command run by an executor without an *exec.ExitError
+	code int
+}
+
+// newExitError returns the ExitError of the command failing with err.
+func newExitError(cmd, stdErr string, err exitCoder) *ExitError {
+	exitErr, _ := err.(*exec.ExitError)
+	return &ExitError{Cmd: cmd, StdErr: stdErr, ExitError: exitErr, code: err.ExitCode()}
+}
+
+// ExitCode returns the exit code of the command.
+func (e *ExitError) ExitCode() int {
+	if e.ExitError != nil {
+		return e.ExitError.ExitCode()
+	}
+	return e.code
+}
+
+func (e *ExitError) Error() string {
+	if e.ExitError != nil {
+		return e.ExitError.Error()
+	}
+	return fmt.Sprintf("exit status %d", e.code)
 }
 
 // IsDebug use for check whether the E2E_TEST "DEBUG" log enabled
@@ -802,21 +936,28 @@ func (c *CLI) Output() (string, error) {
 	}
 
 	finalArgs := c.assembleArgs()
-	cmd := exec.Command(c.execPath, finalArgs...)
-	cmd.Stdin = c.stdin
 	if c.showInfo || IsDebug() {
-		e2e.Logf("Running '%s %s'", c.execPath, strings.Join(finalArgs, " "))
+		e2e.Logf("Running '%s %s'", c.execPath, c.printCmd())
 	}
-	out, err := cmd.CombinedOutput()
-	trimmed := strings.TrimSpace(string(out))
-	e2e.Debugf("****** Output is: ******\n\"%s\"\n****** ErrorInfo is: ******\n\"%v\"", trimmed, err)
-	switch err.(type) {
+	var out bytes.Buffer
+	err := c.runRetrying(finalArgs, func(ctx context.Context, stdin io.Reader) (string, error) {
+		out.Reset()
+		err := c.commandExecutor().Run(ctx, c.execPath, finalArgs, stdin, &out, &out)
+		return strings.TrimSpace(out.String()), err
+	})
+	trimmed := strings.TrimSpace(out.String())
+	e2e.Debugf("****** Output is: ******\n\"%s\"\n****** ErrorInfo is: ******\n\"%v\"", redact.String(trimmed), err)
+	switch err := err.(type) {
 	case nil:
-		c.stdout = bytes.NewBuffer(out)
+		c.stdout = bytes.NewBuffer(out.Bytes())
 		return trimmed, nil
-	case *exec.ExitError:
-		e2e.Logf("Error running %v:\n%s", cmd, trimmed)
-		return trimmed, &ExitError{ExitError: err.(*exec.ExitError), Cmd: c.execPath + " " + strings.Join(finalArgs, " "), StdErr: trimmed}
+	case exitCoder:
+		cmd := c.execPath + " " + c.printCmd()
+		e2e.Logf("Error running %s:\n%s", cmd, redact.String(trimmed))
+		return trimmed, newExitError(cmd, trimmed, err)
+	case *TimeoutError:
+		e2e.Logf("%v", err)
+		return trimmed, err
 	default:
 		FatalErr(fmt.Errorf("unable to execute %q: %v", c.execPath, err))
 		// unreachable code
@@ -830,14 +971,15 @@ func (c *CLI) Outputs() (string, string, error) {
 		fmt.Printf("DEBUG: oc %s\n", c.printCmd())
 	}
 	finalArgs := c.assembleArgs()
-	cmd := exec.Command(c.execPath, finalArgs...)
-	cmd.Stdin = c.stdin
-	e2e.Logf("Running '%s %s'", c.execPath, strings.Join(finalArgs, " "))
+	e2e.Logf("Running '%s %s'", c.execPath, c.printCmd())
 	//out, err := cmd.CombinedOutput()
 	var stdErrBuff, stdOutBuff bytes.Buffer
-	cmd.Stdout = &stdOutBuff
-	cmd.Stderr = &stdErrBuff
-	err := cmd.Run()
+	err := c.runRetrying(finalArgs, func(ctx context.Context, stdin io.Reader) (string, error) {
+		stdOutBuff.Reset()
+		stdErrBuff.Reset()
+		err := c.commandExecutor().Run(ctx, c.execPath, finalArgs, stdin, &stdOutBuff, &stdErrBuff)
+		return strings.TrimSpace(stdOutBuff.String() + "\n" + stdErrBuff.String()), err
+	})
 
 	stdOutBytes := stdOutBuff.Bytes()
 	stdErrBytes := stdErrBuff.Bytes()
@@ -848,8 +990,11 @@ func (c *CLI) Outputs() (string, string, error) {
 		c.stdout = bytes.NewBuffer(stdOutBytes)
 		c.stderr = bytes.NewBuffer(stdErrBytes)
 		return stdOut, stdErr, nil
-	case *exec.ExitError:
-		e2e.Logf("Error running %v:\nStdOut>\n%s\nStdErr>\n%s\n", cmd, stdOut, stdErr)
+	case exitCoder:
+		e2e.Logf("Error running %s %s:\nStdOut>\n%s\nStdErr>\n%s\n", c.execPath, c.printCmd(), redact.String(stdOut), redact.String(stdErr))
+		return stdOut, stdErr, err
+	case *TimeoutError:
+		e2e.Logf("%v", err)
 		return stdOut, stdErr, err
 	default:
 		FatalErr(fmt.Errorf("unable to execute %q: %v", c.execPath, err))
@@ -867,15 +1012,16 @@ func (c *CLI) Background() (*exec.Cmd, *bytes.Buffer, *bytes.Buffer, error) {
 		fmt.Printf("DEBUG: oc %s\n", c.printCmd())
 	}
 	finalArgs := c.assembleArgs()
-	cmd := exec.Command(c.execPath, finalArgs...)
-	cmd.Stdin = c.stdin
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = bufio.NewWriter(&stdout)
-	cmd.Stderr = bufio.NewWriter(&stderr)
 
-	e2e.Logf("Running '%s %s'", c.execPath, strings.Join(finalArgs, " "))
+	e2e.Logf("Running '%s %s'", c.execPath, c.printCmd())
 
-	err := cmd.Start()
+	record := c.newCommandRecord(finalArgs)
+	// the commands in the background run until they are killed or the context of the
+	// CLI is done, without the timeout of the other commands
+	cmd, err := c.commandExecutor().Start(c.parentContext(), c.execPath, finalArgs, c.stdin, bufio.NewWriter(&stdout), bufio.NewWriter(&stderr))
+	record.exitCode = backgroundExitCode
+	recordCommand(record)
 	return cmd, &stdout, &stderr, err
 }
 
@@ -888,17 +1034,25 @@ func (c *CLI) BackgroundRC() (*exec.Cmd, io.ReadCloser, error) {
 		fmt.Printf("DEBUG: oc %s\n", c.printCmd())
 	}
 	finalArgs := c.assembleArgs()
-	cmd := exec.Command(c.execPath, finalArgs...)
-	cmd.Stdin = c.stdin
-	stdout, err := cmd.StdoutPipe()
+	// like cmd.StdoutPipe, the command writes to a pipe whose write end is closed once it
+	// is started, so the reader gets EOF when the command exits
+	stdout, stdoutWriter, err := os.Pipe()
 	if err != nil {
 		return nil, nil, err
 	}
 
-	e2e.Logf("Running '%s %s'", c.execPath, strings.Join(finalArgs, " "))
+	e2e.Logf("Running '%s %s'", c.execPath, c.printCmd())
 
-	err = cmd.Start()
-	return cmd, stdout, err
+	record := c.newCommandRecord(finalArgs)
+	cmd, err := c.commandExecutor().Start(c.parentContext(), c.execPath, finalArgs, c.stdin, stdoutWriter, nil)
+	stdoutWriter.Close()
+	record.exitCode = backgroundExitCode
+	recordCommand(record)
+	if err != nil {
+		stdout.Close()
+		return cmd, nil, err
+	}
+	return cmd, stdout, nil
 }
 
 // OutputToFile executes the command and store output to a file
@@ -1133,6 +1287,12 @@ func (c *CLI) assembleArgs() []string {
 	return finalArgs
 }
 
+// usesWorkSpaceContext returns true if the command runs with the test kubeconfig and the
+// current workspace has a context in it.
+func (c *CLI) usesWorkSpaceContext() bool {
+	return !c.withoutKubeconf && c.configPath == "" && c.workSpaceConfigPath != "" && c.currentWorkSpace.Context != ""
+}
+
 func (c *CLI) isKCPCommand() bool {
 	kcpCommands := []string{"kcp", "ws", "workspaces"}
 	return StrSliceContains(kcpCommands, c.command)
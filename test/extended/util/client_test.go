@@ -0,0 +1,162 @@
+package util
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// testKubeconfig is the shared kubeconfig of the tests, with a kcp-stable-root context
+// and a current context of another kcp.
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: kcp-stable-root
+  cluster: {server: "https://kcp.example.com/clusters/root"}
+- name: kcp-unstable-root
+  cluster: {server: "https://kcp-unstable.example.com:6443/clusters/root"}
+users:
+- name: kcp-admin
+  user: {token: secret}
+contexts:
+- name: kcp-stable-root
+  context: {cluster: kcp-stable-root, user: kcp-admin}
+- name: kcp-unstable-root
+  context: {cluster: kcp-unstable-root, user: kcp-admin}
+current-context: kcp-unstable-root
+`
+
+// setTestKubeconfig makes testKubeconfig the shared kubeconfig of the test.
+func setTestKubeconfig(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := ioutil.WriteFile(path, []byte(testKubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", path)
+	return path
+}
+
+// swapDefaultExecutor runs the commands of the CLIs without an executor with executor
+// until the test ends.
+func swapDefaultExecutor(t *testing.T, executor Executor) {
+	previous := defaultExecutor
+	defaultExecutor = executor
+	t.Cleanup(func() { defaultExecutor = previous })
+}
+
+func Test_loadConfig(t *testing.T) {
+	const (
+		orgQuery  = `-o=jsonpath={.items[?(@.spec.type.name=='organization')].status.URL}`
+		homeQuery = "-o=jsonpath={.status.URL}"
+	)
+	tests := []struct {
+		name        string
+		context     string
+		script      func(fake *FakeExecutor)
+		wantContext string
+		wantRoot    string
+		wantOrg     string
+		wantHome    string
+	}{
+		{
+			name: "default context",
+			script: func(fake *FakeExecutor) {
+				fake.Expect("get", "workspace", "--server=https://kcp.example.com/clusters/root", orgQuery).Returns("https://kcp.example.com/clusters/root:org")
+				fake.Expect("get", "workspace/~", "--server=https://kcp.example.com/clusters/root", homeQuery).Returns("https://kcp.example.com/clusters/root:users:ab:cd:kcp-admin")
+			},
+			wantContext: "kcp-stable-root",
+			wantRoot:    "https://kcp.example.com/clusters/root",
+			wantOrg:     "https://kcp.example.com/clusters/root:org",
+			wantHome:    "https://kcp.example.com/clusters/root:users:ab:cd:kcp-admin",
+		},
+		{
+			name:    "missing context uses the current context",
+			context: "kcp-missing",
+			script: func(fake *FakeExecutor) {
+				fake.Expect("get", "workspace", "--server=https://kcp-unstable.example.com:6443/clusters/root", orgQuery).Returns("https://kcp-unstable.example.com:6443/clusters/root:org")
+				fake.Expect("get", "workspace/~", "--server=https://kcp-unstable.example.com:6443/clusters/root", homeQuery).Returns("https://kcp-unstable.example.com:6443/clusters/root:users:ab:cd:kcp-admin")
+			},
+			wantContext: "kcp-missing",
+			wantRoot:    "https://kcp-unstable.example.com:6443/clusters/root",
+			wantOrg:     "https://kcp-unstable.example.com:6443/clusters/root:org",
+			wantHome:    "https://kcp-unstable.example.com:6443/clusters/root:users:ab:cd:kcp-admin",
+		},
+		{
+			name: "failed queries",
+			script: func(fake *FakeExecutor) {
+				fake.Expect("get", "...").Fails(1, `Error from server (Forbidden): workspaces.tenancy.kcp.dev is forbidden`)
+			},
+			wantContext: "kcp-stable-root",
+			wantRoot:    "https://kcp.example.com/clusters/root",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestKubeconfig(t)
+			t.Setenv("E2E_TEST_CONTEXT", tt.context)
+			fake := &FakeExecutor{}
+			tt.script(fake)
+			swapDefaultExecutor(t, fake)
+
+			loadConfig()
+			if testContext != tt.wantContext {
+				t.Errorf("unexpected test context %q", testContext)
+			}
+			if rootServer != tt.wantRoot {
+				t.Errorf("unexpected root server %q", rootServer)
+			}
+			if orgServer != tt.wantOrg {
+				t.Errorf("unexpected organization server %q", orgServer)
+			}
+			if homeServer != tt.wantHome {
+				t.Errorf("unexpected home server %q", homeServer)
+			}
+			if len(fake.Invocations) != 2 {
+				t.Errorf("unexpected invocations: %v", fake.Invocations)
+			}
+		})
+	}
+}
+
+func Test_logicalClusterServerURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		cluster string
+		want    string
+		wantErr bool
+	}{
+		{name: "path", host: "https://kcp.example.com:6443", cluster: "root:org", want: "https://kcp.example.com:6443/clusters/root:org"},
+		{name: "path of another cluster", host: "https://kcp.example.com/clusters/root", cluster: "root:org", want: "https://kcp.example.com/clusters/root:org"},
+		{name: "base path", host: "https://proxy.example.com/kcp/", cluster: "root:org", want: "https://proxy.example.com/kcp/clusters/root:org"},
+		{name: "base path of another cluster", host: "https://proxy.example.com/kcp/clusters/root", cluster: "root:org", want: "https://proxy.example.com/kcp/clusters/root:org"},
+		{name: "server URL", host: "https://kcp.example.com", cluster: "https://other.example.com/clusters/root:org", want: "https://other.example.com/clusters/root:org"},
+		{name: "no cluster", host: "https://kcp.example.com", wantErr: true},
+		{name: "invalid host", host: "https://kcp.example.com:port", cluster: "root", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := logicalClusterServerURL(tt.host, tt.cluster)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCLI_LogicalClusterConfig(t *testing.T) {
+	k := NewCLIWithExecutor(&FakeExecutor{})
+	k.adminConfigPath = setTestKubeconfig(t)
+	config := k.LogicalClusterConfig("root:org")
+	if config.Host != "https://kcp-unstable.example.com:6443/clusters/root:org" || config.BearerToken != "secret" {
+		t.Errorf("unexpected config %s %q", config.Host, config.BearerToken)
+	}
+
+	k.adminConfigPath = filepath.Join(t.TempDir(), "missing")
+	if failure := assertionFailure(func() { k.LogicalClusterConfig("root:org") }); failure == "" {
+		t.Error("expected a missing kubeconfig to fail")
+	}
+}
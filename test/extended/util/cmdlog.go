@@ -0,0 +1,296 @@
+package util
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kcp-dev/kcp-tests/pkg/redact"
+	g "github.com/onsi/ginkgo"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+const (
+	// commandLogDir is the directory of the artifacts directory the kubectl commands of
+	// every test are recorded in, one subdirectory per test
+	commandLogDir = "kubectl"
+	// maxCommandLogOutput is how much of the output of a command is recorded
+	maxCommandLogOutput = 4096
+	// backgroundExitCode is the exit code recorded for a command started in the background
+	backgroundExitCode = -1
+)
+
+var (
+	commandLogLock sync.Mutex
+	// replayWorkSpaces are the workspaces the replay.sh of the tests are in after their
+	// last command, by log directory
+	replayWorkSpaces = map[string]string{}
+	// unsafeFileChars are replaced in the test names to build the log directory names
+	unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+	// replayTargetFlags select the kubeconfig, the context or the server of a command,
+	// the replay.sh runs the commands against the kubeconfig of the user instead
+	replayTargetFlags = []string{"--kubeconfig", "--context", "--server", "-s"}
+)
+
+// replayHeader starts the replay.sh of a test, it works on a copy of the kubeconfig so
+// the workspace switches do not change the one of the user.
+const replayHeader = `#!/bin/bash
+# Replays the kubectl commands of a test against the kcp of $KUBECONFIG, switching
+# workspaces with "kubectl ws", and the commands run on the pcluster against
+# $PCLUSTER_KUBECONFIG. The credentials are redacted.
+set -e
+replay_kubeconfig="$(mktemp)"
+trap 'rm -f "${replay_kubeconfig}"' EXIT
+cp "${KUBECONFIG:?set KUBECONFIG to the kcp to replay against}" "${replay_kubeconfig}"
+export KUBECONFIG="${replay_kubeconfig}"
+set +e -x
+
+`
+
+// commandRecord is one kubectl invocation of a test.
+type commandRecord struct {
+	execPath string
+	args     []string
+	stdin    string
+	start    time.Time
+	duration time.Duration
+	// exitCode is backgroundExitCode for a command started in the background
+	exitCode int
+	output   string
+	// pcluster is true for a command run with the pcluster kubeconfig
+	pcluster bool
+}
+
+// newCommandRecord returns the record of a command started now with the args and the
+// input of the CLI.
+func (c *CLI) newCommandRecord(args []string) *commandRecord {
+	record := &commandRecord{
+		execPath: c.execPath,
+		args:     args,
+		start:    time.Now(),
+	}
+	if c.stdin != nil {
+		record.stdin = c.stdin.String()
+	}
+	if c.pClusterConfigPath != "" {
+		record.pcluster = StrSliceContains(args, "--kubeconfig="+c.pClusterConfigPath)
+	}
+	return record
+}
+
+// finish sets the duration, exit code and output of a command that returned err.
+func (r *commandRecord) finish(output string, err error) *commandRecord {
+	r.duration = time.Since(r.start)
+	r.output = output
+	switch err := err.(type) {
+	case nil:
+	case exitCoder:
+		r.exitCode = err.ExitCode()
+	default:
+		r.exitCode = 1
+	}
+	return r
+}
+
+// kubeconfig returns the kubeconfig the command runs with.
+func (r *commandRecord) kubeconfig() string {
+	for _, arg := range r.args {
+		if strings.HasPrefix(arg, "--kubeconfig=") {
+			return strings.TrimPrefix(arg, "--kubeconfig=")
+		}
+	}
+	return os.Getenv("KUBECONFIG")
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	if len(s) > 0 && !strings.ContainsAny(s, " \t\n'\"\\$`*?[]{}()<>|&;!#~") {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// logEntry returns the entry of the command in the commands.log of a test.
+func (r *commandRecord) logEntry() string {
+	exitCode := fmt.Sprint(r.exitCode)
+	if r.exitCode == backgroundExitCode {
+		exitCode = "background"
+	}
+	output := redact.String(r.output)
+	if len(output) > maxCommandLogOutput {
+		// cut at the start of a rune so the log stays valid UTF-8
+		n := maxCommandLogOutput
+		for n > 0 && !utf8.RuneStart(output[n]) {
+			n--
+		}
+		output = fmt.Sprintf("%s\n... (%d bytes truncated)", output[:n], len(output)-n)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s kubeconfig=%s duration=%s exit=%s\n", r.start.UTC().Format(time.RFC3339), r.kubeconfig(), r.duration.Round(time.Millisecond), exitCode)
+	fmt.Fprintf(&b, "%s %s\n", r.execPath, strings.Join(redact.Args(r.args), " "))
+	for _, line := range strings.Split(output, "\n") {
+		if len(line) > 0 {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// replayArgs returns the args of the command without the flags selecting its target,
+// see replayTargetFlags, and the logical cluster it runs in, or an empty string.
+func (r *commandRecord) replayArgs() ([]string, string) {
+	var args []string
+	target := map[string]string{}
+	for i := 0; i < len(r.args); i++ {
+		arg := r.args[i]
+		if arg == "--" {
+			// the args of the command run by exec or debug
+			args = append(args, r.args[i:]...)
+			break
+		}
+		flag := ""
+		for _, f := range replayTargetFlags {
+			switch {
+			case arg == f && i+1 < len(r.args):
+				flag = f
+				i++
+				target[f] = r.args[i]
+			case strings.HasPrefix(arg, f+"="):
+				flag = f
+				target[f] = strings.TrimPrefix(arg, f+"=")
+			}
+		}
+		if flag == "" {
+			args = append(args, arg)
+		}
+	}
+	// the contexts of the test kubeconfig are named after the logical clusters, see
+	// workSpaceContextName
+	if context := target["--context"]; len(context) > 0 {
+		return args, context
+	}
+	server := target["--server"]
+	if len(server) == 0 {
+		server = target["-s"]
+	}
+	if i := strings.Index(server, "/clusters/"); i >= 0 {
+		return args, strings.TrimSuffix(server[i+len("/clusters/"):], "/")
+	}
+	return args, ""
+}
+
+// switchesWorkSpace returns true if the command may change the current workspace or
+// context of the kubeconfig, E.g. "ws use" or "config use-context".
+func switchesWorkSpace(args []string) bool {
+	if len(args) > 1 && args[0] == "kcp" {
+		args = args[1:]
+	}
+	return len(args) > 0 && StrSliceContains([]string{"ws", "workspace", "workspaces", "config"}, args[0])
+}
+
+// replayLine returns the command as lines of the replay.sh of a test whose current
+// workspace is workSpace, preceded by a "kubectl ws" to the workspace of the command
+// if it is another one, and the current workspace after the command.
+func (r *commandRecord) replayLine(workSpace string) (string, string) {
+	args, target := r.replayArgs()
+	var b strings.Builder
+	quoted := []string{shellQuote(r.execPath)}
+	if r.pcluster {
+		quoted = append(quoted, `--kubeconfig="${PCLUSTER_KUBECONFIG}"`)
+	} else if len(target) > 0 && target != workSpace {
+		fmt.Fprintf(&b, "%s ws %s\n", shellQuote(r.execPath), shellQuote(target))
+		workSpace = target
+	}
+	for _, arg := range redact.Args(args) {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	b.WriteString(strings.Join(quoted, " "))
+	if r.exitCode == backgroundExitCode {
+		b.WriteString(" &")
+	}
+	if len(r.stdin) > 0 {
+		b.WriteString(" <<'EOF'\n" + strings.TrimSuffix(redact.String(r.stdin), "\n") + "\nEOF")
+	}
+	b.WriteString("\n")
+	if !r.pcluster && switchesWorkSpace(args) {
+		// the next command switches to its workspace again
+		workSpace = ""
+	}
+	return b.String(), workSpace
+}
+
+// commandLogPath returns the directory the commands of the running test are recorded
+// in, or an empty string outside of a test or without an artifacts directory.
+func commandLogPath() string {
+	artifactDir := os.Getenv("ARTIFACT_DIR")
+	if len(artifactDir) == 0 {
+		// also keeps the commands run offline, E.g. by a FakeExecutor, out of ginkgo
+		return ""
+	}
+	name := g.CurrentGinkgoTestDescription().FullTestText
+	if len(name) == 0 {
+		return ""
+	}
+	// the names are cut to stay under the file name limit, the hash keeps them unique
+	safe := strings.Trim(unsafeFileChars.ReplaceAllString(name, "_"), "_")
+	if len(safe) > 100 {
+		safe = safe[:100]
+	}
+	sum := sha256.Sum256([]byte(name))
+	return filepath.Join(artifactDir, commandLogDir, fmt.Sprintf("%s-%x", safe, sum[:4]))
+}
+
+// recordCommand appends the command to the commands.log and the replay.sh of the
+// running test. Failing to record a command is logged and otherwise ignored.
+func recordCommand(r *commandRecord) {
+	dir := commandLogPath()
+	if len(dir) == 0 {
+		return
+	}
+	commandLogLock.Lock()
+	defer commandLogLock.Unlock()
+	if err := appendCommand(dir, r); err != nil {
+		e2e.Logf("Recording the command failed: %v", err)
+	}
+}
+
+func appendCommand(dir string, r *commandRecord) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	log, err := os.OpenFile(filepath.Join(dir, "commands.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+	if _, err := log.WriteString(r.logEntry()); err != nil {
+		return err
+	}
+
+	replayPath := filepath.Join(dir, "replay.sh")
+	_, statErr := os.Stat(replayPath)
+	replay, err := os.OpenFile(replayPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0755)
+	if err != nil {
+		return err
+	}
+	defer replay.Close()
+	if os.IsNotExist(statErr) {
+		delete(replayWorkSpaces, dir)
+		if _, err := replay.WriteString(replayHeader); err != nil {
+			return err
+		}
+	}
+	line, workSpace := r.replayLine(replayWorkSpaces[dir])
+	if _, err := replay.WriteString(line); err != nil {
+		return err
+	}
+	replayWorkSpaces[dir] = workSpace
+	return nil
+}
@@ -0,0 +1,116 @@
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func Test_appendCommand_replay(t *testing.T) {
+	const (
+		kubeconfig = "/tmp/e2e-kubeconfig-1234"
+		pcluster   = "/tmp/pcluster-kubeconfig"
+	)
+	dir := t.TempDir()
+	records := []*commandRecord{
+		{execPath: "kubectl", args: []string{"create", "-f", "-", "--server=https://kcp.example.com/clusters/root:org"}, stdin: "kind: Workspace\n"},
+		{execPath: "kubectl", args: []string{"get", "pods", "--namespace=default", "--kubeconfig=" + kubeconfig, "--context=root:org:e2e-test-kcp-workspace-a1b2c"}},
+		{execPath: "kubectl", args: []string{"apply", "-f", "deployment.yaml", "--kubeconfig", kubeconfig, "--context", "root:org:e2e-test-kcp-workspace-a1b2c"}},
+		{execPath: "kubectl", args: []string{"ws", "use", "..", "--kubeconfig=" + kubeconfig}},
+		{execPath: "kubectl", args: []string{"get", "secret", "--token=abc", "--kubeconfig=" + kubeconfig, "--context=root:org:e2e-test-kcp-workspace-a1b2c"}},
+		{execPath: "kubectl", args: []string{"get", "nodes", "--kubeconfig=" + pcluster}, pcluster: true},
+		{execPath: "kubectl", args: []string{"exec", "web-0", "--server=https://kcp.example.com/clusters/root:org", "--", "curl", "--server=web"}, exitCode: backgroundExitCode},
+	}
+	for _, record := range records {
+		if err := appendCommand(dir, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	replay, err := ioutil.ReadFile(filepath.Join(dir, "replay.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := replayHeader + `kubectl ws root:org
+kubectl create -f - <<'EOF'
+kind: Workspace
+EOF
+kubectl ws root:org:e2e-test-kcp-workspace-a1b2c
+kubectl get pods --namespace=default
+kubectl apply -f deployment.yaml
+kubectl ws use ..
+kubectl ws root:org:e2e-test-kcp-workspace-a1b2c
+kubectl get secret --token=REDACTED
+kubectl --kubeconfig="${PCLUSTER_KUBECONFIG}" get nodes
+kubectl ws root:org
+kubectl exec web-0 -- curl --server=web &
+`
+	if string(replay) != expected {
+		t.Errorf("unexpected replay.sh:\n%s\nexpected:\n%s", replay, expected)
+	}
+}
+
+func Test_commandRecord_replayArgs(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		wantArgs      []string
+		wantWorkSpace string
+	}{
+		{
+			name:     "no target",
+			args:     []string{"get", "pods"},
+			wantArgs: []string{"get", "pods"},
+		},
+		{
+			name:          "server",
+			args:          []string{"get", "pods", "--server=https://kcp.example.com/clusters/root:org/"},
+			wantArgs:      []string{"get", "pods"},
+			wantWorkSpace: "root:org",
+		},
+		{
+			name:          "short server",
+			args:          []string{"get", "-s", "https://kcp.example.com/clusters/root", "pods"},
+			wantArgs:      []string{"get", "pods"},
+			wantWorkSpace: "root",
+		},
+		{
+			name:          "context over server",
+			args:          []string{"get", "pods", "--server=https://kcp.example.com/clusters/root", "--context=root:org"},
+			wantArgs:      []string{"get", "pods"},
+			wantWorkSpace: "root:org",
+		},
+		{
+			name:     "server outside of kcp",
+			args:     []string{"get", "pods", "--server=https://pcluster.example.com:6443", "--kubeconfig=/tmp/kubeconfig"},
+			wantArgs: []string{"get", "pods"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, workSpace := (&commandRecord{args: tt.args}).replayArgs()
+			if fmt.Sprint(args) != fmt.Sprint(tt.wantArgs) {
+				t.Errorf("unexpected args %q", args)
+			}
+			if workSpace != tt.wantWorkSpace {
+				t.Errorf("unexpected workspace %q", workSpace)
+			}
+		})
+	}
+}
+
+func Test_commandRecord_logEntry_truncated(t *testing.T) {
+	// the limit falls in the middle of a two byte rune
+	output := "a" + strings.Repeat("é", maxCommandLogOutput)
+	entry := (&commandRecord{execPath: "kubectl", args: []string{"logs", "web-0"}, output: output}).logEntry()
+	if !utf8.ValidString(entry) {
+		t.Errorf("expected the entry to be valid UTF-8")
+	}
+	kept := maxCommandLogOutput - 1
+	if want := fmt.Sprintf("  a%s\n  ... (%d bytes truncated)\n", strings.Repeat("é", kept/2), len(output)-kept); !strings.Contains(entry, want) {
+		t.Errorf("unexpected entry %q", entry)
+	}
+}
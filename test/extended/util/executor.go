@@ -0,0 +1,217 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+	"sync"
+
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// Executor runs the commands of a CLI, see CLI.WithExecutor.
+type Executor interface {
+	// Run runs the command to completion or until ctx is done, a failed command returns
+	// an error with an ExitCode() int method.
+	Run(ctx context.Context, name string, args []string, stdin io.Reader, stdout, stderr io.Writer) error
+	// Start starts the command in the background, it is killed when ctx is done.
+	Start(ctx context.Context, name string, args []string, stdin io.Reader, stdout, stderr io.Writer) (*exec.Cmd, error)
+}
+
+// exitCoder is implemented by the errors of the commands that ran and failed.
+type exitCoder interface {
+	error
+	ExitCode() int
+}
+
+// defaultExecutor runs the commands of the CLIs without an executor, E.g. the one of loadConfig.
+var defaultExecutor Executor = execExecutor{}
+
+// execExecutor runs the commands with os/exec.
+type execExecutor struct{}
+
+func (execExecutor) Run(ctx context.Context, name string, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, stdout, stderr
+	return cmd.Run()
+}
+
+func (execExecutor) Start(ctx context.Context, name string, args []string, stdin io.Reader, stdout, stderr io.Writer) (*exec.Cmd, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, stdout, stderr
+	return cmd, cmd.Start()
+}
+
+// WithExecutor instructs the commands should be run by the executor
+func (c CLI) WithExecutor(executor Executor) *CLI {
+	c.executor = executor
+	return &c
+}
+
+func (c *CLI) commandExecutor() Executor {
+	if c.executor != nil {
+		return c.executor
+	}
+	return defaultExecutor
+}
+
+// NewCLIWithExecutor returns a CLI outside of the e2e framework that runs its commands
+// with the executor, to test the helpers offline with a FakeExecutor.
+func NewCLIWithExecutor(executor Executor) *CLI {
+	return &CLI{
+		execPath:         "kubectl",
+		executor:         executor,
+		kubeFramework:    &e2e.Framework{BaseName: "kcp-fake"},
+		cleanups:         &cleanupStack{},
+		currentWorkSpace: &WorkSpace{Name: "fakeWorkSpace", ServerURL: "https://kcp.example.com/clusters/root:fake"},
+	}
+}
+
+// FakeExecutor is an Executor that returns the scripted results of the commands
+// instead of running them. E.g.
+//
+//	fake := &exutil.FakeExecutor{}
+//	fake.Expect("get", "synctarget", "...").Returns("NAME   LOCATION   READY")
+//	k := exutil.NewCLIWithExecutor(fake)
+type FakeExecutor struct {
+	lock sync.Mutex
+	// Commands are the scripted commands, the first one matching a command is used.
+	Commands []*FakeCommand
+	// Invocations are the args of every command run.
+	Invocations [][]string
+}
+
+// FakeCommand is the scripted result of the commands matching Args.
+type FakeCommand struct {
+	// Args are the args of the command without the executable. An arg "*" matches any
+	// arg and a last arg "..." matches any remaining args.
+	Args     []string
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	// Times is how many commands the result is returned for, 0 is unlimited. E.g. a
+	// command failing once with a transient error then succeeding is scripted twice.
+	Times int
+	// Hang blocks the command after writing its output until it times out, except in
+	// the background.
+	Hang bool
+
+	used int
+}
+
+// FakeExitError is the error of a scripted command failing.
+type FakeExitError struct {
+	Code int
+}
+
+func (e *FakeExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitCode returns the scripted exit code.
+func (e *FakeExitError) ExitCode() int {
+	return e.Code
+}
+
+// Expect scripts a command matching args, succeeding without output until Returns or Fails.
+func (f *FakeExecutor) Expect(args ...string) *FakeCommand {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	command := &FakeCommand{Args: args}
+	f.Commands = append(f.Commands, command)
+	return command
+}
+
+// Returns sets the stdout of the command.
+func (c *FakeCommand) Returns(stdout string) *FakeCommand {
+	c.Stdout = stdout
+	return c
+}
+
+// Fails sets the exit code and the stderr of the command.
+func (c *FakeCommand) Fails(exitCode int, stderr string) *FakeCommand {
+	c.ExitCode = exitCode
+	c.Stderr = stderr
+	return c
+}
+
+// Hangs blocks the command until it times out, see CLI.WithTimeout. A command started
+// in the background does not block.
+func (c *FakeCommand) Hangs() *FakeCommand {
+	c.Hang = true
+	return c
+}
+
+// Once returns the result of the command for one command only.
+func (c *FakeCommand) Once() *FakeCommand {
+	c.Times = 1
+	return c
+}
+
+func (c *FakeCommand) matches(args []string) bool {
+	if c.Times > 0 && c.used >= c.Times {
+		return false
+	}
+	for i, expected := range c.Args {
+		if expected == "..." && i == len(c.Args)-1 {
+			return true
+		}
+		if i >= len(args) || (expected != "*" && expected != args[i]) {
+			return false
+		}
+	}
+	return len(args) == len(c.Args)
+}
+
+// Run writes the output of the first scripted command matching args, a nil stdout or
+// stderr discards it. A command that is not scripted fails with exit code 127.
+func (f *FakeExecutor) Run(ctx context.Context, name string, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	return f.run(ctx, name, args, stdout, stderr, true)
+}
+
+func (f *FakeExecutor) run(ctx context.Context, name string, args []string, stdout, stderr io.Writer, hang bool) error {
+	if stdout == nil {
+		stdout = ioutil.Discard
+	}
+	if stderr == nil {
+		stderr = ioutil.Discard
+	}
+	command := f.match(args)
+	if command == nil {
+		fmt.Fprintf(stderr, "no scripted command matches %s %s", name, strings.Join(args, " "))
+		return &FakeExitError{Code: 127}
+	}
+	io.WriteString(stdout, command.Stdout)
+	io.WriteString(stderr, command.Stderr)
+	if command.Hang && hang {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if command.ExitCode != 0 {
+		return &FakeExitError{Code: command.ExitCode}
+	}
+	return nil
+}
+
+// match records the command and returns the first scripted command matching args.
+func (f *FakeExecutor) match(args []string) *FakeCommand {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.Invocations = append(f.Invocations, args)
+	for _, command := range f.Commands {
+		if command.matches(args) {
+			command.used++
+			return command
+		}
+	}
+	return nil
+}
+
+// Start writes the output of the command like Run without blocking and returns a
+// command that is not started, so its Wait fails.
+func (f *FakeExecutor) Start(ctx context.Context, name string, args []string, stdin io.Reader, stdout, stderr io.Writer) (*exec.Cmd, error) {
+	return exec.Command(name, args...), f.run(ctx, name, args, stdout, stderr, false)
+}
@@ -0,0 +1,100 @@
+package util
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	o "github.com/onsi/gomega"
+)
+
+// assertionFailed is the panic of a failed gomega assertion, see assertionFailure.
+type assertionFailed string
+
+func init() {
+	o.RegisterFailHandler(func(message string, _ ...int) {
+		panic(assertionFailed(message))
+	})
+}
+
+// assertionFailure runs fn and returns the message of the gomega assertion that failed
+// in it, or an empty string.
+func assertionFailure(fn func()) (message string) {
+	defer func() {
+		if r := recover(); r != nil {
+			failed, ok := r.(assertionFailed)
+			if !ok {
+				panic(r)
+			}
+			message = string(failed)
+		}
+	}()
+	fn()
+	return ""
+}
+
+func TestFakeCommand_matches(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected []string
+		args     []string
+		want     bool
+	}{
+		{name: "equal", expected: []string{"get", "pods"}, args: []string{"get", "pods"}, want: true},
+		{name: "different", expected: []string{"get", "pods"}, args: []string{"get", "nodes"}},
+		{name: "longer", expected: []string{"get"}, args: []string{"get", "pods"}},
+		{name: "shorter", expected: []string{"get", "pods"}, args: []string{"get"}},
+		{name: "any arg", expected: []string{"get", "*", "-o", "json"}, args: []string{"get", "pods", "-o", "json"}, want: true},
+		{name: "remaining args", expected: []string{"get", "..."}, args: []string{"get", "pods", "-o", "json"}, want: true},
+		{name: "no remaining args", expected: []string{"get", "..."}, args: []string{"get"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command := &FakeCommand{Args: tt.expected}
+			if got := command.matches(tt.args); got != tt.want {
+				t.Errorf("expected %t, got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFakeExecutor_Once(t *testing.T) {
+	fake := &FakeExecutor{}
+	fake.Expect("get", "...").Fails(1, "Error from server (InternalError): etcdserver: leader changed").Once()
+	fake.Expect("get", "...").Returns("pod/a")
+	k := NewCLIWithExecutor(fake).WithoutKubeconf().WithoutWorkSpaceServer()
+
+	if _, err := k.Run("get").Args("pods").WithRetry(RetryPolicy{}).Output(); err == nil {
+		t.Fatal("expected the first command to fail")
+	}
+	output, err := k.Run("get").Args("pods").WithRetry(RetryPolicy{}).Output()
+	if err != nil || output != "pod/a" {
+		t.Fatalf("unexpected output %q: %v", output, err)
+	}
+	if len(fake.Invocations) != 2 {
+		t.Errorf("unexpected invocations: %v", fake.Invocations)
+	}
+}
+
+func TestCLI_BackgroundRC(t *testing.T) {
+	fake := &FakeExecutor{}
+	fake.Expect("logs", "-f", "...").Returns("line 1\nline 2\n")
+	k := NewCLIWithExecutor(fake).WithoutKubeconf().WithoutWorkSpaceServer()
+
+	_, stdout, err := k.Run("logs").Args("-f", "deployment/syncer").BackgroundRC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdout.Close()
+	// the reader gets EOF once the command exits
+	output, err := ioutil.ReadAll(stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) != "line 1\nline 2\n" {
+		t.Errorf("unexpected output %q", output)
+	}
+	if want := [][]string{{"logs", "-f", "deployment/syncer"}}; !reflect.DeepEqual(fake.Invocations, want) {
+		t.Errorf("unexpected invocations: %v", fake.Invocations)
+	}
+}
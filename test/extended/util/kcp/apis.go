@@ -0,0 +1,323 @@
+package kcp
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	apiResourceSchemasResource = schema.GroupVersionResource{Group: "apis.kcp.dev", Version: "v1alpha1", Resource: "apiresourceschemas"}
+	apiExportsResource         = schema.GroupVersionResource{Group: "apis.kcp.dev", Version: "v1alpha1", Resource: "apiexports"}
+	apiBindingsResource        = schema.GroupVersionResource{Group: "apis.kcp.dev", Version: "v1alpha1", Resource: "apibindings"}
+)
+
+// APIBinding phases
+const (
+	APIBindingPhaseBinding = "Binding"
+	APIBindingPhaseBound   = "Bound"
+)
+
+// APIResourceSchema is an apis.kcp.dev/v1alpha1 APIResourceSchema. Schemas are usually
+// created from a fixture, E.g. testdata/apibinding/api_rs.yaml.
+type APIResourceSchema struct {
+	unstructured.Unstructured
+}
+
+// Group returns the API group of the schema.
+func (s *APIResourceSchema) Group() string {
+	value, _, _ := unstructured.NestedString(s.Object, "spec", "group")
+	return value
+}
+
+// Plural returns the plural resource name of the schema.
+func (s *APIResourceSchema) Plural() string {
+	value, _, _ := unstructured.NestedString(s.Object, "spec", "names", "plural")
+	return value
+}
+
+// Versions returns the names of the versions of the schema.
+func (s *APIResourceSchema) Versions() []string {
+	items, _, _ := unstructured.NestedSlice(s.Object, "spec", "versions")
+	var versions []string
+	for _, item := range items {
+		if fields, ok := item.(map[string]interface{}); ok {
+			name, _, _ := unstructured.NestedString(fields, "name")
+			versions = append(versions, name)
+		}
+	}
+	return versions
+}
+
+// Conditions returns the status conditions of the schema, schemas have none today.
+func (s *APIResourceSchema) Conditions() []Condition {
+	return conditions(&s.Unstructured)
+}
+
+// APIExport is an apis.kcp.dev/v1alpha1 APIExport.
+type APIExport struct {
+	unstructured.Unstructured
+}
+
+// NewAPIExport returns an APIExport with name of the schemas.
+func NewAPIExport(name string, latestResourceSchemas ...string) *APIExport {
+	e := &APIExport{newObject(apiExportsResource.GroupVersion().String(), "APIExport", name)}
+	unstructured.SetNestedStringSlice(e.Object, latestResourceSchemas, "spec", "latestResourceSchemas")
+	return e
+}
+
+// LatestResourceSchemas returns the names of the schemas exported.
+func (e *APIExport) LatestResourceSchemas() []string {
+	value, _, _ := unstructured.NestedStringSlice(e.Object, "spec", "latestResourceSchemas")
+	return value
+}
+
+// IdentityHash returns the identity hash of the export once it is ready.
+func (e *APIExport) IdentityHash() string {
+	value, _, _ := unstructured.NestedString(e.Object, "status", "identityHash")
+	return value
+}
+
+// VirtualWorkspaceURLs returns the URLs of the virtual workspaces of the export.
+func (e *APIExport) VirtualWorkspaceURLs() []string {
+	items, _, _ := unstructured.NestedSlice(e.Object, "status", "virtualWorkspaces")
+	var urls []string
+	for _, item := range items {
+		if fields, ok := item.(map[string]interface{}); ok {
+			url, _, _ := unstructured.NestedString(fields, "url")
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// Conditions returns the status conditions of the APIExport.
+func (e *APIExport) Conditions() []Condition {
+	return conditions(&e.Unstructured)
+}
+
+// APIBinding is an apis.kcp.dev/v1alpha1 APIBinding.
+type APIBinding struct {
+	unstructured.Unstructured
+}
+
+// NewAPIBinding returns an APIBinding with name.
+func NewAPIBinding(name string) *APIBinding {
+	return &APIBinding{newObject(apiBindingsResource.GroupVersion().String(), "APIBinding", name)}
+}
+
+// SetReference sets the APIExport bound, an empty path is the workspace of the binding.
+func (b *APIBinding) SetReference(path, exportName string) *APIBinding {
+	if len(path) > 0 {
+		unstructured.SetNestedField(b.Object, path, "spec", "reference", "workspace", "path")
+	}
+	unstructured.SetNestedField(b.Object, exportName, "spec", "reference", "workspace", "exportName")
+	return b
+}
+
+// Phase returns the phase of the APIBinding.
+func (b *APIBinding) Phase() string {
+	return phase(&b.Unstructured)
+}
+
+// BoundResources returns the resources bound as resource.group.
+func (b *APIBinding) BoundResources() []string {
+	items, _, _ := unstructured.NestedSlice(b.Object, "status", "boundResources")
+	var resources []string
+	for _, item := range items {
+		if fields, ok := item.(map[string]interface{}); ok {
+			group, _, _ := unstructured.NestedString(fields, "group")
+			resource, _, _ := unstructured.NestedString(fields, "resource")
+			resources = append(resources, resource+"."+group)
+		}
+	}
+	return resources
+}
+
+// Conditions returns the status conditions of the APIBinding.
+func (b *APIBinding) Conditions() []Condition {
+	return conditions(&b.Unstructured)
+}
+
+// APIResourceSchemas manages the APIResourceSchemas of a workspace.
+type APIResourceSchemas struct {
+	r resource
+}
+
+// APIResourceSchemas returns the helpers of the APIResourceSchemas.
+func (c *Client) APIResourceSchemas() *APIResourceSchemas {
+	return &APIResourceSchemas{r: c.resource(apiResourceSchemasResource, "APIResourceSchema")}
+}
+
+func toAPIResourceSchema(obj *unstructured.Unstructured, err error) (*APIResourceSchema, error) {
+	if obj == nil {
+		return nil, err
+	}
+	return &APIResourceSchema{*obj}, err
+}
+
+// Create creates the APIResourceSchema.
+func (c *APIResourceSchemas) Create(obj *APIResourceSchema) (*APIResourceSchema, error) {
+	return toAPIResourceSchema(c.r.create(&obj.Unstructured))
+}
+
+// Get returns the APIResourceSchema with name.
+func (c *APIResourceSchemas) Get(name string) (*APIResourceSchema, error) {
+	return toAPIResourceSchema(c.r.get(name))
+}
+
+// List returns the APIResourceSchemas.
+func (c *APIResourceSchemas) List() ([]*APIResourceSchema, error) {
+	items, err := c.r.list()
+	result := make([]*APIResourceSchema, 0, len(items))
+	for i := range items {
+		result = append(result, &APIResourceSchema{items[i]})
+	}
+	return result, err
+}
+
+// Update updates the APIResourceSchema.
+func (c *APIResourceSchemas) Update(obj *APIResourceSchema) (*APIResourceSchema, error) {
+	return toAPIResourceSchema(c.r.update(&obj.Unstructured))
+}
+
+// Delete deletes the APIResourceSchema with name.
+func (c *APIResourceSchemas) Delete(name string) error {
+	return c.r.delete(name)
+}
+
+// WaitFor waits until done returns true for the APIResourceSchema with name.
+func (c *APIResourceSchemas) WaitFor(name string, timeout time.Duration, done func(*APIResourceSchema) bool) (*APIResourceSchema, error) {
+	return toAPIResourceSchema(c.r.waitFor(name, timeout, func(obj *unstructured.Unstructured) bool {
+		return done(&APIResourceSchema{*obj})
+	}))
+}
+
+// WaitForCondition waits until the APIResourceSchema with name has the condition with status True.
+func (c *APIResourceSchemas) WaitForCondition(name, conditionType string, timeout time.Duration) (*APIResourceSchema, error) {
+	return toAPIResourceSchema(c.r.waitForCondition(name, conditionType, timeout))
+}
+
+// APIExports manages the APIExports of a workspace.
+type APIExports struct {
+	r resource
+}
+
+// APIExports returns the helpers of the APIExports.
+func (c *Client) APIExports() *APIExports {
+	return &APIExports{r: c.resource(apiExportsResource, "APIExport")}
+}
+
+func toAPIExport(obj *unstructured.Unstructured, err error) (*APIExport, error) {
+	if obj == nil {
+		return nil, err
+	}
+	return &APIExport{*obj}, err
+}
+
+// Create creates the APIExport.
+func (c *APIExports) Create(obj *APIExport) (*APIExport, error) {
+	return toAPIExport(c.r.create(&obj.Unstructured))
+}
+
+// Get returns the APIExport with name.
+func (c *APIExports) Get(name string) (*APIExport, error) {
+	return toAPIExport(c.r.get(name))
+}
+
+// List returns the APIExports.
+func (c *APIExports) List() ([]*APIExport, error) {
+	items, err := c.r.list()
+	result := make([]*APIExport, 0, len(items))
+	for i := range items {
+		result = append(result, &APIExport{items[i]})
+	}
+	return result, err
+}
+
+// Update updates the APIExport.
+func (c *APIExports) Update(obj *APIExport) (*APIExport, error) {
+	return toAPIExport(c.r.update(&obj.Unstructured))
+}
+
+// Delete deletes the APIExport with name.
+func (c *APIExports) Delete(name string) error {
+	return c.r.delete(name)
+}
+
+// WaitFor waits until done returns true for the APIExport with name.
+func (c *APIExports) WaitFor(name string, timeout time.Duration, done func(*APIExport) bool) (*APIExport, error) {
+	return toAPIExport(c.r.waitFor(name, timeout, func(obj *unstructured.Unstructured) bool {
+		return done(&APIExport{*obj})
+	}))
+}
+
+// WaitForCondition waits until the APIExport with name has the condition with status True.
+func (c *APIExports) WaitForCondition(name, conditionType string, timeout time.Duration) (*APIExport, error) {
+	return toAPIExport(c.r.waitForCondition(name, conditionType, timeout))
+}
+
+// APIBindings manages the APIBindings of a workspace.
+type APIBindings struct {
+	r resource
+}
+
+// APIBindings returns the helpers of the APIBindings.
+func (c *Client) APIBindings() *APIBindings {
+	return &APIBindings{r: c.resource(apiBindingsResource, "APIBinding")}
+}
+
+func toAPIBinding(obj *unstructured.Unstructured, err error) (*APIBinding, error) {
+	if obj == nil {
+		return nil, err
+	}
+	return &APIBinding{*obj}, err
+}
+
+// Create creates the APIBinding.
+func (c *APIBindings) Create(obj *APIBinding) (*APIBinding, error) {
+	return toAPIBinding(c.r.create(&obj.Unstructured))
+}
+
+// Get returns the APIBinding with name.
+func (c *APIBindings) Get(name string) (*APIBinding, error) {
+	return toAPIBinding(c.r.get(name))
+}
+
+// List returns the APIBindings.
+func (c *APIBindings) List() ([]*APIBinding, error) {
+	items, err := c.r.list()
+	result := make([]*APIBinding, 0, len(items))
+	for i := range items {
+		result = append(result, &APIBinding{items[i]})
+	}
+	return result, err
+}
+
+// Update updates the APIBinding.
+func (c *APIBindings) Update(obj *APIBinding) (*APIBinding, error) {
+	return toAPIBinding(c.r.update(&obj.Unstructured))
+}
+
+// Delete deletes the APIBinding with name.
+func (c *APIBindings) Delete(name string) error {
+	return c.r.delete(name)
+}
+
+// WaitFor waits until done returns true for the APIBinding with name.
+func (c *APIBindings) WaitFor(name string, timeout time.Duration, done func(*APIBinding) bool) (*APIBinding, error) {
+	return toAPIBinding(c.r.waitFor(name, timeout, func(obj *unstructured.Unstructured) bool {
+		return done(&APIBinding{*obj})
+	}))
+}
+
+// WaitForCondition waits until the APIBinding with name has the condition with status True.
+func (c *APIBindings) WaitForCondition(name, conditionType string, timeout time.Duration) (*APIBinding, error) {
+	return toAPIBinding(c.r.waitForCondition(name, conditionType, timeout))
+}
+
+// WaitForPhase waits until the APIBinding with name is in the phase.
+func (c *APIBindings) WaitForPhase(name, phase string, timeout time.Duration) (*APIBinding, error) {
+	return toAPIBinding(c.r.waitForPhase(name, phase, timeout))
+}
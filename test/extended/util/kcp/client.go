@@ -0,0 +1,166 @@
+// Package kcp provides typed helpers for the kcp API objects built on the dynamic
+// client, so tests can assert against objects instead of kubectl jsonpath output.
+//
+// E.g.
+//
+//	c := kcp.NewClient(k.WorkSpaceDynamicClient())
+//	binding, err := c.APIBindings().Create(kcp.NewAPIBinding("cowboys").SetReference("", "today-cowboys"))
+//	o.Expect(err).NotTo(o.HaveOccurred())
+//	binding, err = c.APIBindings().WaitForPhase(binding.GetName(), "Bound", time.Minute)
+package kcp
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+// pollInterval is how often the Wait helpers get the object
+const pollInterval = 2 * time.Second
+
+// Client provides the typed helpers of the kcp API objects of one workspace.
+type Client struct {
+	dynamic dynamic.Interface
+}
+
+// NewClient returns the typed helpers of the workspace the dynamic client is scoped to.
+func NewClient(client dynamic.Interface) *Client {
+	return &Client{dynamic: client}
+}
+
+func (c *Client) resource(gvr schema.GroupVersionResource, kind string) resource {
+	return resource{
+		client:     c.dynamic.Resource(gvr),
+		apiVersion: gvr.GroupVersion().String(),
+		kind:       kind,
+	}
+}
+
+// Condition is a condition of the status of a kcp API object.
+type Condition struct {
+	Type    string
+	Status  string
+	Reason  string
+	Message string
+}
+
+// conditions returns the status conditions of an object.
+func conditions(obj *unstructured.Unstructured) []Condition {
+	items, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	result := make([]Condition, 0, len(items))
+	for _, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		condition := Condition{}
+		condition.Type, _, _ = unstructured.NestedString(fields, "type")
+		condition.Status, _, _ = unstructured.NestedString(fields, "status")
+		condition.Reason, _, _ = unstructured.NestedString(fields, "reason")
+		condition.Message, _, _ = unstructured.NestedString(fields, "message")
+		result = append(result, condition)
+	}
+	return result
+}
+
+// isConditionTrue returns true if the object has the condition with status True.
+func isConditionTrue(obj *unstructured.Unstructured, conditionType string) bool {
+	for _, condition := range conditions(obj) {
+		if condition.Type == conditionType {
+			return condition.Status == "True"
+		}
+	}
+	return false
+}
+
+// phase returns the status phase of an object.
+func phase(obj *unstructured.Unstructured) string {
+	value, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	return value
+}
+
+// newObject returns an object of kind with name.
+func newObject(apiVersion, kind, name string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetName(name)
+	return obj
+}
+
+// resource implements the helpers shared by every kind on one cluster scoped resource.
+type resource struct {
+	client     dynamic.ResourceInterface
+	apiVersion string
+	kind       string
+}
+
+func (r resource) create(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if len(obj.GetAPIVersion()) == 0 {
+		obj.SetAPIVersion(r.apiVersion)
+	}
+	if len(obj.GetKind()) == 0 {
+		obj.SetKind(r.kind)
+	}
+	return r.client.Create(obj, metav1.CreateOptions{})
+}
+
+func (r resource) get(name string) (*unstructured.Unstructured, error) {
+	return r.client.Get(name, metav1.GetOptions{})
+}
+
+func (r resource) list() ([]unstructured.Unstructured, error) {
+	list, err := r.client.List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (r resource) update(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	return r.client.Update(obj, metav1.UpdateOptions{})
+}
+
+func (r resource) delete(name string) error {
+	return r.client.Delete(name, &metav1.DeleteOptions{})
+}
+
+// waitFor gets the object until done returns true for it, or fails after timeout with
+// the last error or the state of the object.
+func (r resource) waitFor(name string, timeout time.Duration, done func(*unstructured.Unstructured) bool) (*unstructured.Unstructured, error) {
+	var (
+		obj     *unstructured.Unstructured
+		lastErr error
+	)
+	err := wait.PollImmediate(pollInterval, timeout, func() (bool, error) {
+		obj, lastErr = r.get(name)
+		if lastErr != nil {
+			return false, nil
+		}
+		return done(obj), nil
+	})
+	if err == nil {
+		return obj, nil
+	}
+	if lastErr != nil {
+		return nil, fmt.Errorf("%s %q: %v: %v", r.kind, name, err, lastErr)
+	}
+	return obj, fmt.Errorf("%s %q: %v, phase %q, conditions %v", r.kind, name, err, phase(obj), conditions(obj))
+}
+
+func (r resource) waitForCondition(name, conditionType string, timeout time.Duration) (*unstructured.Unstructured, error) {
+	return r.waitFor(name, timeout, func(obj *unstructured.Unstructured) bool {
+		return isConditionTrue(obj, conditionType)
+	})
+}
+
+func (r resource) waitForPhase(name, expected string, timeout time.Duration) (*unstructured.Unstructured, error) {
+	return r.waitFor(name, timeout, func(obj *unstructured.Unstructured) bool {
+		return phase(obj) == expected
+	})
+}
@@ -0,0 +1,359 @@
+package kcp
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func Test_conditions(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     interface{}
+		want       []Condition
+		wantReady  bool
+		wantPhase  string
+		omitStatus bool
+	}{
+		{
+			name:       "missing status",
+			omitStatus: true,
+			want:       []Condition{},
+		},
+		{
+			name: "ready",
+			status: map[string]interface{}{
+				"phase": "Ready",
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Ready", "status": "True"},
+					map[string]interface{}{"type": "Initialized", "status": "False", "reason": "InitializerExists", "message": "pending"},
+				},
+			},
+			want: []Condition{
+				{Type: "Ready", Status: "True"},
+				{Type: "Initialized", Status: "False", Reason: "InitializerExists", Message: "pending"},
+			},
+			wantReady: true,
+			wantPhase: "Ready",
+		},
+		{
+			name: "not ready",
+			status: map[string]interface{}{
+				"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "False"}},
+			},
+			want: []Condition{{Type: "Ready", Status: "False"}},
+		},
+		{
+			name:   "status not an object",
+			status: "Ready",
+			want:   []Condition{},
+		},
+		{
+			name:   "conditions not a list",
+			status: map[string]interface{}{"conditions": "Ready", "phase": 1},
+			want:   []Condition{},
+		},
+		{
+			name: "malformed conditions",
+			status: map[string]interface{}{
+				"conditions": []interface{}{
+					"Ready",
+					map[string]interface{}{"type": "Ready", "status": true},
+				},
+			},
+			want: []Condition{{Type: "Ready"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := newObject("tenancy.kcp.dev/v1beta1", "Workspace", "a")
+			if !tt.omitStatus {
+				obj.Object["status"] = tt.status
+			}
+			if got := conditions(&obj); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected conditions %#v", got)
+			}
+			if got := isConditionTrue(&obj, "Ready"); got != tt.wantReady {
+				t.Errorf("expected Ready %t, got %t", tt.wantReady, got)
+			}
+			if got := phase(&obj); got != tt.wantPhase {
+				t.Errorf("expected phase %q, got %q", tt.wantPhase, got)
+			}
+		})
+	}
+}
+
+func Test_resource_create(t *testing.T) {
+	tests := []struct {
+		name           string
+		obj            unstructured.Unstructured
+		wantAPIVersion string
+		wantKind       string
+	}{
+		{
+			name:           "defaulted",
+			obj:            newObject("", "", "a"),
+			wantAPIVersion: "tenancy.kcp.dev/v1beta1",
+			wantKind:       "Workspace",
+		},
+		{
+			name:           "set",
+			obj:            newObject("tenancy.kcp.dev/v1alpha1", "ClusterWorkspace", "a"),
+			wantAPIVersion: "tenancy.kcp.dev/v1alpha1",
+			wantKind:       "ClusterWorkspace",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewClient(fake.NewSimpleDynamicClient(runtime.NewScheme())).resource(workspacesResource, "Workspace")
+			created, err := r.create(&tt.obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if created.GetAPIVersion() != tt.wantAPIVersion || created.GetKind() != tt.wantKind {
+				t.Errorf("unexpected object %s %s", created.GetAPIVersion(), created.GetKind())
+			}
+		})
+	}
+}
+
+func Test_resource_waitFor(t *testing.T) {
+	initializing := NewWorkspace("initializing")
+	initializing.Object["status"] = map[string]interface{}{
+		"phase":      "Initializing",
+		"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "False", "reason": "InitializerExists"}},
+	}
+	ready := NewWorkspace("ready")
+	ready.Object["status"] = map[string]interface{}{"phase": "Ready"}
+	r := NewClient(fake.NewSimpleDynamicClient(runtime.NewScheme(), &initializing.Unstructured, &ready.Unstructured)).resource(workspacesResource, "Workspace")
+
+	tests := []struct {
+		name      string
+		wantObj   bool
+		wantError string
+	}{
+		{name: "ready", wantObj: true},
+		{
+			name:      "initializing",
+			wantObj:   true,
+			wantError: `Workspace "initializing": timed out waiting for the condition, phase "Initializing", conditions [{Ready False InitializerExists }]`,
+		},
+		{
+			name:      "missing",
+			wantError: `Workspace "missing": timed out waiting for the condition: workspaces.tenancy.kcp.dev "missing" not found`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := r.waitForPhase(tt.name, WorkspacePhaseReady, 10*time.Millisecond)
+			if (obj != nil) != tt.wantObj {
+				t.Errorf("unexpected object %v", obj)
+			}
+			switch {
+			case len(tt.wantError) == 0 && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case len(tt.wantError) > 0 && (err == nil || err.Error() != tt.wantError):
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestClient_roundTrip(t *testing.T) {
+	// every kind is created, listed, updated with a label, got and deleted
+	tests := []struct {
+		kind      string
+		roundTrip func(c *Client) error
+	}{
+		{
+			kind: "Workspace",
+			roundTrip: func(c *Client) error {
+				return roundTrip(
+					func() (*unstructured.Unstructured, error) {
+						return unwrap(c.Workspaces().Create(NewWorkspace("a").SetType("root", "universal")))
+					},
+					func() (int, error) { items, err := c.Workspaces().List(); return len(items), err },
+					func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+						return unwrap(c.Workspaces().Update(&Workspace{*obj}))
+					},
+					func() (*unstructured.Unstructured, error) { return unwrap(c.Workspaces().Get("a")) },
+					func() error { return c.Workspaces().Delete("a") },
+				)
+			},
+		},
+		{
+			kind: "ClusterWorkspaceType",
+			roundTrip: func(c *Client) error {
+				return roundTrip(
+					func() (*unstructured.Unstructured, error) {
+						return unwrap(c.ClusterWorkspaceTypes().Create(NewClusterWorkspaceType("a").SetExtend("root", "universal")))
+					},
+					func() (int, error) { items, err := c.ClusterWorkspaceTypes().List(); return len(items), err },
+					func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+						return unwrap(c.ClusterWorkspaceTypes().Update(&ClusterWorkspaceType{*obj}))
+					},
+					func() (*unstructured.Unstructured, error) { return unwrap(c.ClusterWorkspaceTypes().Get("a")) },
+					func() error { return c.ClusterWorkspaceTypes().Delete("a") },
+				)
+			},
+		},
+		{
+			kind: "APIResourceSchema",
+			roundTrip: func(c *Client) error {
+				return roundTrip(
+					func() (*unstructured.Unstructured, error) {
+						return unwrap(c.APIResourceSchemas().Create(&APIResourceSchema{newObject("", "", "a")}))
+					},
+					func() (int, error) { items, err := c.APIResourceSchemas().List(); return len(items), err },
+					func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+						return unwrap(c.APIResourceSchemas().Update(&APIResourceSchema{*obj}))
+					},
+					func() (*unstructured.Unstructured, error) { return unwrap(c.APIResourceSchemas().Get("a")) },
+					func() error { return c.APIResourceSchemas().Delete("a") },
+				)
+			},
+		},
+		{
+			kind: "APIExport",
+			roundTrip: func(c *Client) error {
+				return roundTrip(
+					func() (*unstructured.Unstructured, error) {
+						return unwrap(c.APIExports().Create(NewAPIExport("a", "today.cowboys.wildwest.dev")))
+					},
+					func() (int, error) { items, err := c.APIExports().List(); return len(items), err },
+					func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+						return unwrap(c.APIExports().Update(&APIExport{*obj}))
+					},
+					func() (*unstructured.Unstructured, error) { return unwrap(c.APIExports().Get("a")) },
+					func() error { return c.APIExports().Delete("a") },
+				)
+			},
+		},
+		{
+			kind: "APIBinding",
+			roundTrip: func(c *Client) error {
+				return roundTrip(
+					func() (*unstructured.Unstructured, error) {
+						return unwrap(c.APIBindings().Create(NewAPIBinding("a").SetReference("root:org", "a")))
+					},
+					func() (int, error) { items, err := c.APIBindings().List(); return len(items), err },
+					func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+						return unwrap(c.APIBindings().Update(&APIBinding{*obj}))
+					},
+					func() (*unstructured.Unstructured, error) { return unwrap(c.APIBindings().Get("a")) },
+					func() error { return c.APIBindings().Delete("a") },
+				)
+			},
+		},
+		{
+			kind: "SyncTarget",
+			roundTrip: func(c *Client) error {
+				return roundTrip(
+					func() (*unstructured.Unstructured, error) { return unwrap(c.SyncTargets().Create(NewSyncTarget("a"))) },
+					func() (int, error) { items, err := c.SyncTargets().List(); return len(items), err },
+					func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+						return unwrap(c.SyncTargets().Update(&SyncTarget{*obj}))
+					},
+					func() (*unstructured.Unstructured, error) { return unwrap(c.SyncTargets().Get("a")) },
+					func() error { return c.SyncTargets().Delete("a") },
+				)
+			},
+		},
+		{
+			kind: "Location",
+			roundTrip: func(c *Client) error {
+				return roundTrip(
+					func() (*unstructured.Unstructured, error) {
+						return unwrap(c.Locations().Create(NewLocation("a", map[string]string{"region": "east"})))
+					},
+					func() (int, error) { items, err := c.Locations().List(); return len(items), err },
+					func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+						return unwrap(c.Locations().Update(&Location{*obj}))
+					},
+					func() (*unstructured.Unstructured, error) { return unwrap(c.Locations().Get("a")) },
+					func() error { return c.Locations().Delete("a") },
+				)
+			},
+		},
+		{
+			kind: "Placement",
+			roundTrip: func(c *Client) error {
+				return roundTrip(
+					func() (*unstructured.Unstructured, error) {
+						return unwrap(c.Placements().Create(NewPlacement("a", "root:org", map[string]string{"region": "east"}, map[string]string{"team": "e2e"})))
+					},
+					func() (int, error) { items, err := c.Placements().List(); return len(items), err },
+					func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+						return unwrap(c.Placements().Update(&Placement{*obj}))
+					},
+					func() (*unstructured.Unstructured, error) { return unwrap(c.Placements().Get("a")) },
+					func() error { return c.Placements().Delete("a") },
+				)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			if err := tt.roundTrip(NewClient(fake.NewSimpleDynamicClient(runtime.NewScheme()))); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// unstructuredObject is implemented by every kind of the package.
+type unstructuredObject interface {
+	UnstructuredContent() map[string]interface{}
+}
+
+// unwrap returns the unstructured object of a kind returned with err.
+func unwrap(obj unstructuredObject, err error) (*unstructured.Unstructured, error) {
+	if err != nil || reflect.ValueOf(obj).IsNil() {
+		return nil, err
+	}
+	return &unstructured.Unstructured{Object: obj.UnstructuredContent()}, nil
+}
+
+// roundTrip runs the helpers of a kind on the object "a" and returns an error if one
+// of them fails or returns an unexpected object.
+func roundTrip(
+	create func() (*unstructured.Unstructured, error),
+	list func() (int, error),
+	update func(*unstructured.Unstructured) (*unstructured.Unstructured, error),
+	get func() (*unstructured.Unstructured, error),
+	deleteFn func() error,
+) error {
+	created, err := create()
+	if err != nil {
+		return fmt.Errorf("create: %v", err)
+	}
+	if created.GetName() != "a" || len(created.GetAPIVersion()) == 0 || len(created.GetKind()) == 0 {
+		return fmt.Errorf("unexpected created object %s %s %q", created.GetAPIVersion(), created.GetKind(), created.GetName())
+	}
+	if n, err := list(); err != nil || n != 1 {
+		return fmt.Errorf("list: %d objects: %v", n, err)
+	}
+	created.SetLabels(map[string]string{"team": "e2e"})
+	if _, err := update(created); err != nil {
+		return fmt.Errorf("update: %v", err)
+	}
+	got, err := get()
+	if err != nil {
+		return fmt.Errorf("get: %v", err)
+	}
+	if got.GetLabels()["team"] != "e2e" || !reflect.DeepEqual(got.Object["spec"], created.Object["spec"]) {
+		return fmt.Errorf("unexpected object %v", got.Object)
+	}
+	if err := deleteFn(); err != nil {
+		return fmt.Errorf("delete: %v", err)
+	}
+	if _, err := get(); err == nil {
+		return fmt.Errorf("expected the object to be deleted")
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package kcp
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	locationsResource  = schema.GroupVersionResource{Group: "scheduling.kcp.dev", Version: "v1alpha1", Resource: "locations"}
+	placementsResource = schema.GroupVersionResource{Group: "scheduling.kcp.dev", Version: "v1alpha1", Resource: "placements"}
+)
+
+// Placement phases
+const (
+	PlacementPhasePending = "Pending"
+	PlacementPhaseBound   = "Bound"
+	PlacementPhaseUnbound = "Unbound"
+)
+
+// Location is a scheduling.kcp.dev/v1alpha1 Location.
+type Location struct {
+	unstructured.Unstructured
+}
+
+// NewLocation returns a Location with name of the SyncTargets matching the labels.
+func NewLocation(name string, instanceLabels map[string]string) *Location {
+	l := &Location{newObject(locationsResource.GroupVersion().String(), "Location", name)}
+	unstructured.SetNestedField(l.Object, "workload.kcp.dev", "spec", "resource", "group")
+	unstructured.SetNestedField(l.Object, "v1alpha1", "spec", "resource", "version")
+	unstructured.SetNestedField(l.Object, "synctargets", "spec", "resource", "resource")
+	unstructured.SetNestedStringMap(l.Object, instanceLabels, "spec", "instanceSelector", "matchLabels")
+	return l
+}
+
+// Instances returns the number of instances of the Location.
+func (l *Location) Instances() int64 {
+	value, _, _ := unstructured.NestedInt64(l.Object, "status", "instances")
+	return value
+}
+
+// AvailableInstances returns the number of ready instances of the Location.
+func (l *Location) AvailableInstances() int64 {
+	value, _, _ := unstructured.NestedInt64(l.Object, "status", "availableInstances")
+	return value
+}
+
+// Conditions returns the status conditions of the Location.
+func (l *Location) Conditions() []Condition {
+	return conditions(&l.Unstructured)
+}
+
+// Placement is a scheduling.kcp.dev/v1alpha1 Placement.
+type Placement struct {
+	unstructured.Unstructured
+}
+
+// NewPlacement returns a Placement with name of the namespaces matching the labels to
+// the Locations of the location workspace matching the location labels.
+func NewPlacement(name, locationWorkspace string, locationLabels, namespaceLabels map[string]string) *Placement {
+	p := &Placement{newObject(placementsResource.GroupVersion().String(), "Placement", name)}
+	unstructured.SetNestedField(p.Object, locationWorkspace, "spec", "locationWorkspace")
+	unstructured.SetNestedField(p.Object, "workload.kcp.dev", "spec", "locationResource", "group")
+	unstructured.SetNestedField(p.Object, "v1alpha1", "spec", "locationResource", "version")
+	unstructured.SetNestedField(p.Object, "synctargets", "spec", "locationResource", "resource")
+	locationSelector := map[string]interface{}{"matchLabels": stringMap(locationLabels)}
+	unstructured.SetNestedSlice(p.Object, []interface{}{locationSelector}, "spec", "locationSelectors")
+	unstructured.SetNestedStringMap(p.Object, namespaceLabels, "spec", "namespaceSelector", "matchLabels")
+	return p
+}
+
+// Phase returns the phase of the Placement.
+func (p *Placement) Phase() string {
+	return phase(&p.Unstructured)
+}
+
+// SelectedLocation returns the workspace path and name of the Location selected.
+func (p *Placement) SelectedLocation() (path, name string) {
+	path, _, _ = unstructured.NestedString(p.Object, "status", "selectedLocation", "path")
+	name, _, _ = unstructured.NestedString(p.Object, "status", "selectedLocation", "locationName")
+	return path, name
+}
+
+// Conditions returns the status conditions of the Placement.
+func (p *Placement) Conditions() []Condition {
+	return conditions(&p.Unstructured)
+}
+
+func stringMap(m map[string]string) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
+
+// Locations manages the Locations of a workspace.
+type Locations struct {
+	r resource
+}
+
+// Locations returns the helpers of the Locations.
+func (c *Client) Locations() *Locations {
+	return &Locations{r: c.resource(locationsResource, "Location")}
+}
+
+func toLocation(obj *unstructured.Unstructured, err error) (*Location, error) {
+	if obj == nil {
+		return nil, err
+	}
+	return &Location{*obj}, err
+}
+
+// Create creates the Location.
+func (c *Locations) Create(obj *Location) (*Location, error) {
+	return toLocation(c.r.create(&obj.Unstructured))
+}
+
+// Get returns the Location with name.
+func (c *Locations) Get(name string) (*Location, error) {
+	return toLocation(c.r.get(name))
+}
+
+// List returns the Locations.
+func (c *Locations) List() ([]*Location, error) {
+	items, err := c.r.list()
+	result := make([]*Location, 0, len(items))
+	for i := range items {
+		result = append(result, &Location{items[i]})
+	}
+	return result, err
+}
+
+// Update updates the Location.
+func (c *Locations) Update(obj *Location) (*Location, error) {
+	return toLocation(c.r.update(&obj.Unstructured))
+}
+
+// Delete deletes the Location with name.
+func (c *Locations) Delete(name string) error {
+	return c.r.delete(name)
+}
+
+// WaitFor waits until done returns true for the Location with name.
+func (c *Locations) WaitFor(name string, timeout time.Duration, done func(*Location) bool) (*Location, error) {
+	return toLocation(c.r.waitFor(name, timeout, func(obj *unstructured.Unstructured) bool {
+		return done(&Location{*obj})
+	}))
+}
+
+// WaitForCondition waits until the Location with name has the condition with status True.
+func (c *Locations) WaitForCondition(name, conditionType string, timeout time.Duration) (*Location, error) {
+	return toLocation(c.r.waitForCondition(name, conditionType, timeout))
+}
+
+// Placements manages the Placements of a workspace.
+type Placements struct {
+	r resource
+}
+
+// Placements returns the helpers of the Placements.
+func (c *Client) Placements() *Placements {
+	return &Placements{r: c.resource(placementsResource, "Placement")}
+}
+
+func toPlacement(obj *unstructured.Unstructured, err error) (*Placement, error) {
+	if obj == nil {
+		return nil, err
+	}
+	return &Placement{*obj}, err
+}
+
+// Create creates the Placement.
+func (c *Placements) Create(obj *Placement) (*Placement, error) {
+	return toPlacement(c.r.create(&obj.Unstructured))
+}
+
+// Get returns the Placement with name.
+func (c *Placements) Get(name string) (*Placement, error) {
+	return toPlacement(c.r.get(name))
+}
+
+// List returns the Placements.
+func (c *Placements) List() ([]*Placement, error) {
+	items, err := c.r.list()
+	result := make([]*Placement, 0, len(items))
+	for i := range items {
+		result = append(result, &Placement{items[i]})
+	}
+	return result, err
+}
+
+// Update updates the Placement.
+func (c *Placements) Update(obj *Placement) (*Placement, error) {
+	return toPlacement(c.r.update(&obj.Unstructured))
+}
+
+// Delete deletes the Placement with name.
+func (c *Placements) Delete(name string) error {
+	return c.r.delete(name)
+}
+
+// WaitFor waits until done returns true for the Placement with name.
+func (c *Placements) WaitFor(name string, timeout time.Duration, done func(*Placement) bool) (*Placement, error) {
+	return toPlacement(c.r.waitFor(name, timeout, func(obj *unstructured.Unstructured) bool {
+		return done(&Placement{*obj})
+	}))
+}
+
+// WaitForCondition waits until the Placement with name has the condition with status True.
+func (c *Placements) WaitForCondition(name, conditionType string, timeout time.Duration) (*Placement, error) {
+	return toPlacement(c.r.waitForCondition(name, conditionType, timeout))
+}
+
+// WaitForPhase waits until the Placement with name is in the phase.
+func (c *Placements) WaitForPhase(name, phase string, timeout time.Duration) (*Placement, error) {
+	return toPlacement(c.r.waitForPhase(name, phase, timeout))
+}
@@ -0,0 +1,217 @@
+package kcp
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	workspacesResource            = schema.GroupVersionResource{Group: "tenancy.kcp.dev", Version: "v1beta1", Resource: "workspaces"}
+	clusterWorkspaceTypesResource = schema.GroupVersionResource{Group: "tenancy.kcp.dev", Version: "v1alpha1", Resource: "clusterworkspacetypes"}
+)
+
+// Workspace phases
+const (
+	WorkspacePhaseScheduling   = "Scheduling"
+	WorkspacePhaseInitializing = "Initializing"
+	WorkspacePhaseReady        = "Ready"
+)
+
+// Workspace is a tenancy.kcp.dev/v1beta1 Workspace.
+type Workspace struct {
+	unstructured.Unstructured
+}
+
+// NewWorkspace returns a Workspace with name of the default type.
+func NewWorkspace(name string) *Workspace {
+	return &Workspace{newObject(workspacesResource.GroupVersion().String(), "Workspace", name)}
+}
+
+// SetType sets the ClusterWorkspaceType of the Workspace, E.g. ("root", "universal").
+func (w *Workspace) SetType(path, name string) *Workspace {
+	unstructured.SetNestedField(w.Object, path, "spec", "type", "path")
+	unstructured.SetNestedField(w.Object, name, "spec", "type", "name")
+	return w
+}
+
+// Type returns the name of the ClusterWorkspaceType of the Workspace.
+func (w *Workspace) Type() string {
+	value, _, _ := unstructured.NestedString(w.Object, "spec", "type", "name")
+	return value
+}
+
+// Phase returns the phase of the Workspace.
+func (w *Workspace) Phase() string {
+	return phase(&w.Unstructured)
+}
+
+// URL returns the server URL of the Workspace once it is scheduled.
+func (w *Workspace) URL() string {
+	value, _, _ := unstructured.NestedString(w.Object, "status", "URL")
+	return value
+}
+
+// Conditions returns the status conditions of the Workspace.
+func (w *Workspace) Conditions() []Condition {
+	return conditions(&w.Unstructured)
+}
+
+// Initializers returns the initializers still pending on the Workspace, E.g. while it
+// is Initializing.
+func (w *Workspace) Initializers() []string {
+	value, _, _ := unstructured.NestedStringSlice(w.Object, "status", "initializers")
+	return value
+}
+
+// ClusterWorkspaceType is a tenancy.kcp.dev/v1alpha1 ClusterWorkspaceType.
+type ClusterWorkspaceType struct {
+	unstructured.Unstructured
+}
+
+// NewClusterWorkspaceType returns a ClusterWorkspaceType with name.
+func NewClusterWorkspaceType(name string) *ClusterWorkspaceType {
+	return &ClusterWorkspaceType{newObject(clusterWorkspaceTypesResource.GroupVersion().String(), "ClusterWorkspaceType", name)}
+}
+
+// SetExtend sets the ClusterWorkspaceTypes the type extends, E.g. ("root", "universal").
+func (t *ClusterWorkspaceType) SetExtend(path, name string) *ClusterWorkspaceType {
+	unstructured.SetNestedSlice(t.Object, []interface{}{map[string]interface{}{"path": path, "name": name}}, "spec", "extend", "with")
+	return t
+}
+
+// SetDefaultChildWorkspaceType sets the type of the child workspaces created without a type.
+func (t *ClusterWorkspaceType) SetDefaultChildWorkspaceType(path, name string) *ClusterWorkspaceType {
+	unstructured.SetNestedField(t.Object, path, "spec", "defaultChildWorkspaceType", "path")
+	unstructured.SetNestedField(t.Object, name, "spec", "defaultChildWorkspaceType", "name")
+	return t
+}
+
+// Conditions returns the status conditions of the ClusterWorkspaceType.
+func (t *ClusterWorkspaceType) Conditions() []Condition {
+	return conditions(&t.Unstructured)
+}
+
+// Workspaces manages the Workspaces of a workspace.
+type Workspaces struct {
+	r resource
+}
+
+// Workspaces returns the helpers of the Workspaces.
+func (c *Client) Workspaces() *Workspaces {
+	return &Workspaces{r: c.resource(workspacesResource, "Workspace")}
+}
+
+func toWorkspace(obj *unstructured.Unstructured, err error) (*Workspace, error) {
+	if obj == nil {
+		return nil, err
+	}
+	return &Workspace{*obj}, err
+}
+
+// Create creates the Workspace.
+func (c *Workspaces) Create(obj *Workspace) (*Workspace, error) {
+	return toWorkspace(c.r.create(&obj.Unstructured))
+}
+
+// Get returns the Workspace with name.
+func (c *Workspaces) Get(name string) (*Workspace, error) {
+	return toWorkspace(c.r.get(name))
+}
+
+// List returns the Workspaces.
+func (c *Workspaces) List() ([]*Workspace, error) {
+	items, err := c.r.list()
+	result := make([]*Workspace, 0, len(items))
+	for i := range items {
+		result = append(result, &Workspace{items[i]})
+	}
+	return result, err
+}
+
+// Update updates the Workspace.
+func (c *Workspaces) Update(obj *Workspace) (*Workspace, error) {
+	return toWorkspace(c.r.update(&obj.Unstructured))
+}
+
+// Delete deletes the Workspace with name.
+func (c *Workspaces) Delete(name string) error {
+	return c.r.delete(name)
+}
+
+// WaitFor waits until done returns true for the Workspace with name.
+func (c *Workspaces) WaitFor(name string, timeout time.Duration, done func(*Workspace) bool) (*Workspace, error) {
+	return toWorkspace(c.r.waitFor(name, timeout, func(obj *unstructured.Unstructured) bool {
+		return done(&Workspace{*obj})
+	}))
+}
+
+// WaitForCondition waits until the Workspace with name has the condition with status True.
+func (c *Workspaces) WaitForCondition(name, conditionType string, timeout time.Duration) (*Workspace, error) {
+	return toWorkspace(c.r.waitForCondition(name, conditionType, timeout))
+}
+
+// WaitForPhase waits until the Workspace with name is in the phase.
+func (c *Workspaces) WaitForPhase(name, phase string, timeout time.Duration) (*Workspace, error) {
+	return toWorkspace(c.r.waitForPhase(name, phase, timeout))
+}
+
+// ClusterWorkspaceTypes manages the ClusterWorkspaceTypes of a workspace.
+type ClusterWorkspaceTypes struct {
+	r resource
+}
+
+// ClusterWorkspaceTypes returns the helpers of the ClusterWorkspaceTypes.
+func (c *Client) ClusterWorkspaceTypes() *ClusterWorkspaceTypes {
+	return &ClusterWorkspaceTypes{r: c.resource(clusterWorkspaceTypesResource, "ClusterWorkspaceType")}
+}
+
+func toClusterWorkspaceType(obj *unstructured.Unstructured, err error) (*ClusterWorkspaceType, error) {
+	if obj == nil {
+		return nil, err
+	}
+	return &ClusterWorkspaceType{*obj}, err
+}
+
+// Create creates the ClusterWorkspaceType.
+func (c *ClusterWorkspaceTypes) Create(obj *ClusterWorkspaceType) (*ClusterWorkspaceType, error) {
+	return toClusterWorkspaceType(c.r.create(&obj.Unstructured))
+}
+
+// Get returns the ClusterWorkspaceType with name.
+func (c *ClusterWorkspaceTypes) Get(name string) (*ClusterWorkspaceType, error) {
+	return toClusterWorkspaceType(c.r.get(name))
+}
+
+// List returns the ClusterWorkspaceTypes.
+func (c *ClusterWorkspaceTypes) List() ([]*ClusterWorkspaceType, error) {
+	items, err := c.r.list()
+	result := make([]*ClusterWorkspaceType, 0, len(items))
+	for i := range items {
+		result = append(result, &ClusterWorkspaceType{items[i]})
+	}
+	return result, err
+}
+
+// Update updates the ClusterWorkspaceType.
+func (c *ClusterWorkspaceTypes) Update(obj *ClusterWorkspaceType) (*ClusterWorkspaceType, error) {
+	return toClusterWorkspaceType(c.r.update(&obj.Unstructured))
+}
+
+// Delete deletes the ClusterWorkspaceType with name.
+func (c *ClusterWorkspaceTypes) Delete(name string) error {
+	return c.r.delete(name)
+}
+
+// WaitFor waits until done returns true for the ClusterWorkspaceType with name.
+func (c *ClusterWorkspaceTypes) WaitFor(name string, timeout time.Duration, done func(*ClusterWorkspaceType) bool) (*ClusterWorkspaceType, error) {
+	return toClusterWorkspaceType(c.r.waitFor(name, timeout, func(obj *unstructured.Unstructured) bool {
+		return done(&ClusterWorkspaceType{*obj})
+	}))
+}
+
+// WaitForCondition waits until the ClusterWorkspaceType with name has the condition with status True.
+func (c *ClusterWorkspaceTypes) WaitForCondition(name, conditionType string, timeout time.Duration) (*ClusterWorkspaceType, error) {
+	return toClusterWorkspaceType(c.r.waitForCondition(name, conditionType, timeout))
+}
@@ -0,0 +1,100 @@
+package kcp
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var syncTargetsResource = schema.GroupVersionResource{Group: "workload.kcp.dev", Version: "v1alpha1", Resource: "synctargets"}
+
+// SyncTargetReady is the condition of a SyncTarget whose syncer is heartbeating.
+const SyncTargetReady = "Ready"
+
+// SyncTarget is a workload.kcp.dev/v1alpha1 SyncTarget. SyncTargets are usually created
+// by "kubectl kcp workload sync", see the syncer package.
+type SyncTarget struct {
+	unstructured.Unstructured
+}
+
+// NewSyncTarget returns a SyncTarget with name.
+func NewSyncTarget(name string) *SyncTarget {
+	return &SyncTarget{newObject(syncTargetsResource.GroupVersion().String(), "SyncTarget", name)}
+}
+
+// SyncedResources returns the resources synced to the physical cluster.
+func (t *SyncTarget) SyncedResources() []string {
+	value, _, _ := unstructured.NestedStringSlice(t.Object, "status", "syncedResources")
+	return value
+}
+
+// Unschedulable returns true if no new workload is scheduled to the SyncTarget.
+func (t *SyncTarget) Unschedulable() bool {
+	value, _, _ := unstructured.NestedBool(t.Object, "spec", "unschedulable")
+	return value
+}
+
+// Conditions returns the status conditions of the SyncTarget.
+func (t *SyncTarget) Conditions() []Condition {
+	return conditions(&t.Unstructured)
+}
+
+// SyncTargets manages the SyncTargets of a workspace.
+type SyncTargets struct {
+	r resource
+}
+
+// SyncTargets returns the helpers of the SyncTargets.
+func (c *Client) SyncTargets() *SyncTargets {
+	return &SyncTargets{r: c.resource(syncTargetsResource, "SyncTarget")}
+}
+
+func toSyncTarget(obj *unstructured.Unstructured, err error) (*SyncTarget, error) {
+	if obj == nil {
+		return nil, err
+	}
+	return &SyncTarget{*obj}, err
+}
+
+// Create creates the SyncTarget.
+func (c *SyncTargets) Create(obj *SyncTarget) (*SyncTarget, error) {
+	return toSyncTarget(c.r.create(&obj.Unstructured))
+}
+
+// Get returns the SyncTarget with name.
+func (c *SyncTargets) Get(name string) (*SyncTarget, error) {
+	return toSyncTarget(c.r.get(name))
+}
+
+// List returns the SyncTargets.
+func (c *SyncTargets) List() ([]*SyncTarget, error) {
+	items, err := c.r.list()
+	result := make([]*SyncTarget, 0, len(items))
+	for i := range items {
+		result = append(result, &SyncTarget{items[i]})
+	}
+	return result, err
+}
+
+// Update updates the SyncTarget.
+func (c *SyncTargets) Update(obj *SyncTarget) (*SyncTarget, error) {
+	return toSyncTarget(c.r.update(&obj.Unstructured))
+}
+
+// Delete deletes the SyncTarget with name.
+func (c *SyncTargets) Delete(name string) error {
+	return c.r.delete(name)
+}
+
+// WaitFor waits until done returns true for the SyncTarget with name.
+func (c *SyncTargets) WaitFor(name string, timeout time.Duration, done func(*SyncTarget) bool) (*SyncTarget, error) {
+	return toSyncTarget(c.r.waitFor(name, timeout, func(obj *unstructured.Unstructured) bool {
+		return done(&SyncTarget{*obj})
+	}))
+}
+
+// WaitForCondition waits until the SyncTarget with name has the condition with status True.
+func (c *SyncTargets) WaitForCondition(name, conditionType string, timeout time.Duration) (*SyncTarget, error) {
+	return toSyncTarget(c.r.waitForCondition(name, conditionType, timeout))
+}
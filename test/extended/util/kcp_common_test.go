@@ -0,0 +1,58 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestGetKcpServerVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		command func(fake *FakeExecutor) *FakeCommand
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "release",
+			command: func(fake *FakeExecutor) *FakeCommand {
+				return fake.Expect("version", "...").Returns(`{"serverVersion": {"gitVersion": "v1.24.3+kcp-v0.8.0", "gitCommit": "a1b2c3"}}`)
+			},
+			want: "v0.8.0",
+		},
+		{
+			name: "development build",
+			command: func(fake *FakeExecutor) *FakeCommand {
+				return fake.Expect("version", "...").Returns(`{"serverVersion": {"gitVersion": "v1.24.3+kcp-v0.8.0-14-ga1b2c3d", "gitCommit": "a1b2c3"}}`)
+			},
+			want: "v0.8.0",
+		},
+		{
+			name: "not kcp",
+			command: func(fake *FakeExecutor) *FakeCommand {
+				return fake.Expect("version", "...").Returns(`{"serverVersion": {"gitVersion": "v1.24.3", "gitCommit": "a1b2c3"}}`)
+			},
+		},
+		{
+			name: "failed",
+			command: func(fake *FakeExecutor) *FakeCommand {
+				return fake.Expect("version", "...").Fails(1, "error: You must be logged in to the server (Unauthorized)")
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &FakeExecutor{}
+			tt.command(fake)
+			got, err := GetKcpServerVersion(NewCLIWithExecutor(fake))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected version %q, got %q", tt.want, got)
+			}
+			if len(fake.Invocations) != 1 || fake.Invocations[0][0] != "version" {
+				t.Errorf("unexpected invocations: %v", fake.Invocations)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// workSpaceContextName returns the name of the context of the workspace in the private
+// kubeconfig of a test, its logical cluster path. E.g. root:orgID:e2e-test-kcp-workspace-xxxxx
+func workSpaceContextName(ws *WorkSpace) string {
+	if i := strings.Index(ws.ServerURL, "/clusters/"); i >= 0 {
+		return ws.ServerURL[i+len("/clusters/"):]
+	}
+	return ws.Name
+}
+
+// baseContext returns the cluster and the user of the test context of the shared
+// kubeconfig, or of its current context if the test context does not exist.
+func baseContext(config *clientcmdapi.Config) (*clientcmdapi.Cluster, string, *clientcmdapi.AuthInfo, error) {
+	name := testContext
+	if _, ok := config.Contexts[name]; !ok {
+		name = config.CurrentContext
+	}
+	context, ok := config.Contexts[name]
+	if !ok {
+		return nil, "", nil, fmt.Errorf("context %q does not exist in kubeconfig %q", name, KubeConfigPath())
+	}
+	cluster, ok := config.Clusters[context.Cluster]
+	if !ok {
+		return nil, "", nil, fmt.Errorf("cluster %q of context %q does not exist in kubeconfig %q", context.Cluster, name, KubeConfigPath())
+	}
+	authInfo, ok := config.AuthInfos[context.AuthInfo]
+	if !ok {
+		return nil, "", nil, fmt.Errorf("user %q of context %q does not exist in kubeconfig %q", context.AuthInfo, name, KubeConfigPath())
+	}
+	return cluster, context.AuthInfo, authInfo, nil
+}
+
+// addWorkSpaceContext adds a context for the workspace to the private kubeconfig of the
+// test and, if current, makes it the current context. The kubeconfig is created on the
+// first workspace of the test with the credentials of the shared kubeconfig, so the
+// tests switching contexts, E.g. with "kubectl kcp ws use", do not change the shared one.
+// It has no current context until a workspace is entered, the commands of the CLI pick
+// the context of their workspace anyway.
+func (c *CLI) addWorkSpaceContext(ws *WorkSpace, current bool) error {
+	shared, err := clientcmd.LoadFromFile(KubeConfigPath())
+	if err != nil {
+		return err
+	}
+	baseCluster, authInfoName, authInfo, err := baseContext(shared)
+	if err != nil {
+		return err
+	}
+
+	config := clientcmdapi.NewConfig()
+	if c.workSpaceConfigPath == "" {
+		f, err := ioutil.TempFile("", "e2e-kubeconfig-")
+		if err != nil {
+			return err
+		}
+		f.Close()
+		c.workSpaceConfigPath = f.Name()
+		e2e.Logf("Workspace kubeconfig is %q", c.workSpaceConfigPath)
+	} else if config, err = clientcmd.LoadFromFile(c.workSpaceConfigPath); err != nil {
+		return err
+	}
+
+	name := workSpaceContextName(ws)
+	cluster := baseCluster.DeepCopy()
+	cluster.Server = ws.ServerURL
+	config.Clusters[name] = cluster
+	config.AuthInfos[authInfoName] = authInfo.DeepCopy()
+	config.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: authInfoName}
+	if current {
+		config.CurrentContext = name
+	}
+	if err := clientcmd.WriteToFile(*config, c.workSpaceConfigPath); err != nil {
+		return err
+	}
+	ws.Context = name
+	return nil
+}
+
+// removeWorkSpaceKubeconfig removes the private kubeconfig of the test.
+func (c *CLI) removeWorkSpaceKubeconfig() {
+	if len(c.workSpaceConfigPath) == 0 {
+		return
+	}
+	if err := os.Remove(c.workSpaceConfigPath); err != nil && !os.IsNotExist(err) {
+		e2e.Logf("Removing the workspace kubeconfig failed: %v", err)
+	}
+	c.workSpaceConfigPath = ""
+}
+
+// WorkSpaceConfigPath returns the private kubeconfig of the test, with a context for
+// every workspace created by the test, or an empty string before the first one.
+func (c *CLI) WorkSpaceConfigPath() string {
+	return c.workSpaceConfigPath
+}
@@ -0,0 +1,145 @@
+package util
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// setTestContext makes context the test context of the shared kubeconfig until the test ends.
+func setTestContext(t *testing.T, context string) {
+	previous := testContext
+	testContext = context
+	t.Cleanup(func() { testContext = previous })
+}
+
+func Test_workSpaceContextName(t *testing.T) {
+	for serverURL, want := range map[string]string{
+		"https://kcp.example.com/clusters/root:org:e2e-test-kcp-workspace-a1b2c": "root:org:e2e-test-kcp-workspace-a1b2c",
+		"https://kcp.example.com/base/clusters/root":                             "root",
+		"https://kcp.example.com":                                                "e2e-test-kcp-workspace-a1b2c",
+	} {
+		if got := workSpaceContextName(&WorkSpace{Name: "e2e-test-kcp-workspace-a1b2c", ServerURL: serverURL}); got != want {
+			t.Errorf("%s: expected %q, got %q", serverURL, want, got)
+		}
+	}
+}
+
+func Test_baseContext(t *testing.T) {
+	tests := []struct {
+		name        string
+		testContext string
+		wantServer  string
+	}{
+		{name: "test context", testContext: "kcp-stable-root", wantServer: "https://kcp.example.com/clusters/root"},
+		{name: "missing test context", testContext: "kcp-missing", wantServer: "https://kcp-unstable.example.com:6443/clusters/root"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestContext(t, tt.testContext)
+			config, err := clientcmd.Load([]byte(testKubeconfig))
+			if err != nil {
+				t.Fatal(err)
+			}
+			cluster, authInfoName, authInfo, err := baseContext(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cluster.Server != tt.wantServer || authInfoName != "kcp-admin" || authInfo.Token != "secret" {
+				t.Errorf("unexpected context: %s %s %#v", cluster.Server, authInfoName, authInfo)
+			}
+		})
+	}
+
+	t.Run("missing current context", func(t *testing.T) {
+		setTestContext(t, "kcp-missing")
+		config, err := clientcmd.Load([]byte(testKubeconfig))
+		if err != nil {
+			t.Fatal(err)
+		}
+		config.CurrentContext = ""
+		if _, _, _, err := baseContext(config); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestCLI_addWorkSpaceContext(t *testing.T) {
+	shared := setTestKubeconfig(t)
+	setTestContext(t, "kcp-stable-root")
+	fake := &FakeExecutor{}
+	fake.Expect("...")
+	k := NewCLIWithExecutor(fake)
+	defer k.removeWorkSpaceKubeconfig()
+
+	first := &WorkSpace{Name: "a", ServerURL: "https://kcp.example.com/clusters/root:org:a"}
+	if err := k.addWorkSpaceContext(first, true); err != nil {
+		t.Fatal(err)
+	}
+	path := k.WorkSpaceConfigPath()
+	if path == "" || path == shared {
+		t.Fatalf("unexpected workspace kubeconfig %q", path)
+	}
+	second := &WorkSpace{Name: "b", ServerURL: "https://kcp.example.com/clusters/root:org:b"}
+	if err := k.addWorkSpaceContext(second, false); err != nil {
+		t.Fatal(err)
+	}
+	if k.WorkSpaceConfigPath() != path {
+		t.Fatalf("expected the workspace kubeconfig to be reused, got %q", k.WorkSpaceConfigPath())
+	}
+	if first.Context != "root:org:a" || second.Context != "root:org:b" {
+		t.Errorf("unexpected contexts %q and %q", first.Context, second.Context)
+	}
+
+	config, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.CurrentContext != "root:org:a" {
+		t.Errorf("unexpected current context %q", config.CurrentContext)
+	}
+	for _, ws := range []*WorkSpace{first, second} {
+		context, ok := config.Contexts[ws.Context]
+		if !ok {
+			t.Fatalf("missing context %q", ws.Context)
+		}
+		if server := config.Clusters[context.Cluster].Server; server != ws.ServerURL {
+			t.Errorf("unexpected server %q of context %q", server, ws.Context)
+		}
+		if authInfo := config.AuthInfos[context.AuthInfo]; authInfo == nil || authInfo.Token != "secret" {
+			t.Errorf("unexpected user %q of context %q", context.AuthInfo, ws.Context)
+		}
+	}
+
+	// the commands run in the current workspace with its context, the config commands
+	// work on the workspace kubeconfig itself
+	k.currentWorkSpace = first
+	if err := k.WithoutNamespace().Run("get").Args("pods").Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.WithoutNamespace().Run("config").Args("current-context").Execute(); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"get", "pods", "--kubeconfig=" + path, "--context=root:org:a"},
+		{"config", "current-context", "--kubeconfig=" + path},
+	}
+	if !reflect.DeepEqual(fake.Invocations, want) {
+		t.Errorf("unexpected invocations: %q", fake.Invocations)
+	}
+
+	k.removeWorkSpaceKubeconfig()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the workspace kubeconfig to be removed: %v", err)
+	}
+	if k.WorkSpaceConfigPath() != "" {
+		t.Errorf("unexpected workspace kubeconfig %q", k.WorkSpaceConfigPath())
+	}
+	if data, err := ioutil.ReadFile(shared); err != nil || !bytes.Equal(data, []byte(testKubeconfig)) {
+		t.Errorf("expected the shared kubeconfig to be unchanged: %v", err)
+	}
+}
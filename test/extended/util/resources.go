@@ -0,0 +1,28 @@
+package util
+
+import (
+	"fmt"
+	"os"
+
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// recordTestResource appends name to TEST_RESOURCES_FILE, the file the suite runner
+// sets for every test to learn the workspaces and namespaces it created. The runner
+// uses them to tell the monitor events of the test from the events of the tests
+// running in parallel. Nothing is recorded when the test is run on its own.
+func recordTestResource(name string) {
+	path := os.Getenv("TEST_RESOURCES_FILE")
+	if len(path) == 0 {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		e2e.Logf("Could not record the test resource %q: %v", name, err)
+		return
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintln(f, name); err != nil {
+		e2e.Logf("Could not record the test resource %q: %v", name, err)
+	}
+}
@@ -0,0 +1,25 @@
+package util
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func Test_recordTestResource(t *testing.T) {
+	// a test run on its own records nothing
+	t.Setenv("TEST_RESOURCES_FILE", "")
+	recordTestResource("e2e-test-kcp-workspace-abcde")
+
+	path := filepath.Join(t.TempDir(), "resources")
+	t.Setenv("TEST_RESOURCES_FILE", path)
+	recordTestResource("e2e-test-kcp-workspace-abcde")
+	recordTestResource("e2e-ns-kcp-workspace-fghij")
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "e2e-test-kcp-workspace-abcde\ne2e-ns-kcp-workspace-fghij\n" {
+		t.Errorf("unexpected resources %q", data)
+	}
+}
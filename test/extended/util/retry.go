@@ -0,0 +1,160 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/kcp-tests/pkg/redact"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// transientErrors match the output of the commands failing with a transient kcp or
+// network error, worth running again.
+var transientErrors = regexp.MustCompile(strings.Join([]string{
+	`connection refused`,
+	`connection reset by peer`,
+	`i/o timeout`,
+	`TLS handshake timeout`,
+	`http2: client connection lost`,
+	`unexpected EOF`,
+	`(?i:status code|status|code|HTTP/[0-9.]+):? 503\b`,
+	`Service Unavailable`,
+	`\(ServiceUnavailable\)`,
+	`the server is currently unable to handle the request`,
+	`the server was unable to return a response in the time allotted`,
+	`etcdserver: leader changed`,
+	`etcdserver: request timed out`,
+}, "|"))
+
+// nonIdempotentVerbs are the commands that may have taken effect before failing, so
+// running them again is not safe by default. kcp commands are checked on their
+// subcommand, E.g. "ws create" or "kcp bind compute".
+var nonIdempotentVerbs = []string{"create", "run", "expose", "exec", "cp", "patch", "bind"}
+
+// RetryPolicy re-runs the commands failing with a transient error, see transientErrors.
+type RetryPolicy struct {
+	// Attempts is the maximum number of runs of a command, 1 or less disables retries.
+	Attempts int
+	// Interval is the wait before the first retry, doubled before every further retry.
+	Interval time.Duration
+	// NonIdempotent also retries the commands like create that may have taken effect
+	// before failing.
+	NonIdempotent bool
+}
+
+// DefaultRetryPolicy returns the policy of the commands run without WithRetry. It
+// retries the idempotent commands 3 times every 2s, E2E_TEST_RETRY_ATTEMPTS and
+// E2E_TEST_RETRY_INTERVAL override the attempts and the interval, E.g. 1 and 5s.
+func DefaultRetryPolicy() RetryPolicy {
+	policy := RetryPolicy{Attempts: 3, Interval: 2 * time.Second}
+	if value := os.Getenv("E2E_TEST_RETRY_ATTEMPTS"); len(value) > 0 {
+		attempts, err := strconv.Atoi(value)
+		if err != nil {
+			e2e.Logf(`Ignoring invalid E2E_TEST_RETRY_ATTEMPTS "%s": %v`, value, err)
+		} else {
+			policy.Attempts = attempts
+		}
+	}
+	if value := os.Getenv("E2E_TEST_RETRY_INTERVAL"); len(value) > 0 {
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			e2e.Logf(`Ignoring invalid E2E_TEST_RETRY_INTERVAL "%s": %v`, value, err)
+		} else {
+			policy.Interval = interval
+		}
+	}
+	return policy
+}
+
+// WithRetry instructs the command should be run again with the policy when it fails
+// with a transient error
+func (c CLI) WithRetry(policy RetryPolicy) *CLI {
+	c.retry = &policy
+	return &c
+}
+
+// retryPolicy returns the policy of the command.
+func (c *CLI) retryPolicy() RetryPolicy {
+	if c.retry != nil {
+		return *c.retry
+	}
+	return DefaultRetryPolicy()
+}
+
+// isNonIdempotent returns true if the command may have taken effect before failing.
+func (c *CLI) isNonIdempotent() bool {
+	verbs := []string{c.command}
+	if c.isKCPCommand() {
+		// E.g. "ws create" or "kcp ws create"
+		verbs = append(verbs, c.commandArgs[:minInt(2, len(c.commandArgs))]...)
+	}
+	for _, verb := range verbs {
+		if StrSliceContains(nonIdempotentVerbs, verb) {
+			return true
+		}
+	}
+	return false
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// retries returns true if a command that failed with output and err on the attempt
+// should be run again.
+func (c *CLI) retries(policy RetryPolicy, attempt int, output string, err error) bool {
+	if _, ok := err.(exitCoder); !ok {
+		return false
+	}
+	if attempt >= policy.Attempts || !transientErrors.MatchString(output) {
+		return false
+	}
+	return policy.NonIdempotent || !c.isNonIdempotent()
+}
+
+// runRetrying runs the command with run until it succeeds, fails with an error that is
+// not transient, the retry policy gives up or the context of the CLI is done, and
+// returns the error of the last run. Every run gets the input of the CLI and its own
+// timeout, see WithTimeout, and is recorded, see recordCommand. A run that times out is
+// not retried.
+func (c *CLI) runRetrying(args []string, run func(ctx context.Context, stdin io.Reader) (output string, err error)) error {
+	var input []byte
+	if c.stdin != nil {
+		input = c.stdin.Bytes()
+	}
+	policy := c.retryPolicy()
+	delay := policy.Interval
+	for attempt := 1; ; attempt++ {
+		record := c.newCommandRecord(args)
+		ctx, cancel := c.commandContext()
+		output, err := run(ctx, bytes.NewReader(input))
+		if ctx.Err() != nil {
+			err = &TimeoutError{Cmd: c.execPath + " " + strings.Join(redact.Args(args), " "), Timeout: c.commandTimeout(), Output: output, Err: ctx.Err()}
+		}
+		cancel()
+		recordCommand(record.finish(output, err))
+		if !c.retries(policy, attempt, output, err) {
+			return err
+		}
+		e2e.Logf("Retrying '%s %s' in %s after a transient error (attempt %d/%d): %s", c.execPath, strings.Join(redact.Args(args), " "), delay, attempt, policy.Attempts, redact.String(output))
+		// the wait ends with the context of the CLI, see WithContext
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-c.parentContext().Done():
+			timer.Stop()
+			e2e.Logf("Not retrying '%s %s': %v", c.execPath, strings.Join(redact.Args(args), " "), c.parentContext().Err())
+			return err
+		}
+		delay *= 2
+	}
+}
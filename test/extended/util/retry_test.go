@@ -0,0 +1,184 @@
+package util
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_transientErrors(t *testing.T) {
+	tests := []struct {
+		output string
+		want   bool
+	}{
+		{output: `The connection to the server kcp.example.com:6443 was refused - did you specify the right host or port?: dial tcp 10.0.0.1:6443: connect: connection refused`, want: true},
+		{output: `Unable to connect to the server: net/http: TLS handshake timeout`, want: true},
+		{output: `Error from server (ServiceUnavailable): the server is currently unable to handle the request (get workspaces.tenancy.kcp.dev)`, want: true},
+		{output: `Error from server: etcdserver: leader changed`, want: true},
+		{output: `error: unexpected status code 503`, want: true},
+		{output: `the server responded with HTTP/1.1 503 Service Unavailable`, want: true},
+		{output: `error: code: 503, reason: ServiceUnavailable`, want: true},
+		{output: `Error from server (NotFound): workspaces.tenancy.kcp.dev "e2e-test-kcp-workspace-503ab" not found`},
+		{output: `deployment.apps/web-503 created`},
+		{output: `pod/web-0 restarted 503 times`},
+		{output: `Error from server (Forbidden): workspaces.tenancy.kcp.dev is forbidden`},
+		{output: `Error from server (AlreadyExists): workspaces.tenancy.kcp.dev "a" already exists`},
+	}
+	for _, tt := range tests {
+		if got := transientErrors.MatchString(tt.output); got != tt.want {
+			t.Errorf("%s: expected transient %t, got %t", tt.output, tt.want, got)
+		}
+	}
+}
+
+func TestCLI_isNonIdempotent(t *testing.T) {
+	tests := []struct {
+		command string
+		args    []string
+		want    bool
+	}{
+		{command: "get", args: []string{"pods"}},
+		{command: "apply", args: []string{"-f", "deployment.yaml"}},
+		{command: "delete", args: []string{"pod", "web-0"}},
+		{command: "create", args: []string{"-f", "-"}, want: true},
+		{command: "patch", args: []string{"deployment", "web"}, want: true},
+		{command: "exec", args: []string{"web-0", "--", "ls"}, want: true},
+		{command: "ws", args: []string{"create", "a"}, want: true},
+		{command: "ws", args: []string{"use", "a"}},
+		{command: "kcp", args: []string{"ws", "create", "a"}, want: true},
+		{command: "kcp", args: []string{"bind", "compute", "root:org:ws"}, want: true},
+		{command: "kcp", args: []string{"workload", "sync", "syncer"}},
+		{command: "get", args: []string{"bind"}},
+	}
+	for _, tt := range tests {
+		k := &CLI{command: tt.command, commandArgs: tt.args}
+		if got := k.isNonIdempotent(); got != tt.want {
+			t.Errorf("%s %v: expected non-idempotent %t, got %t", tt.command, tt.args, tt.want, got)
+		}
+	}
+}
+
+func TestCLI_runRetrying(t *testing.T) {
+	const transient = "Error from server: etcdserver: leader changed"
+	policy := RetryPolicy{Attempts: 3, Interval: time.Millisecond}
+	tests := []struct {
+		name            string
+		command         []string
+		policy          RetryPolicy
+		script          func(fake *FakeExecutor)
+		wantErr         bool
+		wantInvocations int
+	}{
+		{
+			name:    "succeeds",
+			command: []string{"get", "pods"},
+			policy:  policy,
+			script: func(fake *FakeExecutor) {
+				fake.Expect("get", "...").Returns("pod/web-0")
+			},
+			wantInvocations: 1,
+		},
+		{
+			name:    "succeeds after a transient error",
+			command: []string{"get", "pods"},
+			policy:  policy,
+			script: func(fake *FakeExecutor) {
+				fake.Expect("get", "...").Fails(1, transient).Once()
+				fake.Expect("get", "...").Returns("pod/web-0")
+			},
+			wantInvocations: 2,
+		},
+		{
+			name:    "gives up",
+			command: []string{"get", "pods"},
+			policy:  policy,
+			script: func(fake *FakeExecutor) {
+				fake.Expect("get", "...").Fails(1, transient)
+			},
+			wantErr:         true,
+			wantInvocations: 3,
+		},
+		{
+			name:    "not transient",
+			command: []string{"get", "pods"},
+			policy:  policy,
+			script: func(fake *FakeExecutor) {
+				fake.Expect("get", "...").Fails(1, `Error from server (NotFound): pods "web-0" not found`)
+			},
+			wantErr:         true,
+			wantInvocations: 1,
+		},
+		{
+			name:    "retries disabled",
+			command: []string{"get", "pods"},
+			policy:  RetryPolicy{Attempts: 1, Interval: time.Millisecond},
+			script: func(fake *FakeExecutor) {
+				fake.Expect("get", "...").Fails(1, transient)
+			},
+			wantErr:         true,
+			wantInvocations: 1,
+		},
+		{
+			name:    "non-idempotent",
+			command: []string{"kcp", "bind", "compute", "root:org:ws"},
+			policy:  policy,
+			script: func(fake *FakeExecutor) {
+				fake.Expect("kcp", "...").Fails(1, transient)
+			},
+			wantErr:         true,
+			wantInvocations: 1,
+		},
+		{
+			name:    "non-idempotent allowed",
+			command: []string{"create", "-f", "-"},
+			policy:  RetryPolicy{Attempts: 3, Interval: time.Millisecond, NonIdempotent: true},
+			script: func(fake *FakeExecutor) {
+				fake.Expect("create", "...").Fails(1, transient).Once()
+				fake.Expect("create", "...").Returns("workspace.tenancy.kcp.dev/a created")
+			},
+			wantInvocations: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &FakeExecutor{}
+			tt.script(fake)
+			k := NewCLIWithExecutor(fake).WithoutNamespace().WithoutKubeconf().WithoutWorkSpaceServer().WithRetry(tt.policy)
+
+			_, err := k.Run(tt.command[0]).Args(tt.command[1:]...).Output()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(fake.Invocations) != tt.wantInvocations {
+				t.Errorf("expected %d invocations, got %v", tt.wantInvocations, fake.Invocations)
+			}
+		})
+	}
+}
+
+func TestCLI_runRetrying_contextDone(t *testing.T) {
+	fake := &FakeExecutor{}
+	fake.Expect("get", "...").Fails(1, "Error from server: etcdserver: leader changed")
+	// the first run fails at once, the wait before the retry ends with the context
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	k := NewCLIWithExecutor(fake).WithoutNamespace().WithoutKubeconf().WithoutWorkSpaceServer().
+		WithRetry(RetryPolicy{Attempts: 3, Interval: time.Hour}).WithContext(ctx)
+
+	done := make(chan error)
+	go func() {
+		_, err := k.Run("get").Args("pods").Output()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if _, ok := err.(exitCoder); !ok {
+			t.Errorf("expected the error of the command, got %v", err)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("the retry did not stop with the context")
+	}
+	if len(fake.Invocations) != 1 {
+		t.Errorf("unexpected invocations: %v", fake.Invocations)
+	}
+}
@@ -0,0 +1,85 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kcp-dev/kcp-tests/pkg/redact"
+	e2e "k8s.io/kubernetes/test/e2e/framework"
+)
+
+// DefaultCommandTimeout returns the timeout of the commands run without WithTimeout,
+// 10m. E2E_TEST_COMMAND_TIMEOUT overrides it, E.g. 2m, and 0 disables it. The commands
+// started by Background and BackgroundRC have no timeout, they run until they are
+// killed or the context of the CLI is done, see WithContext.
+func DefaultCommandTimeout() time.Duration {
+	timeout := 10 * time.Minute
+	if value := os.Getenv("E2E_TEST_COMMAND_TIMEOUT"); len(value) > 0 {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			e2e.Logf(`Ignoring invalid E2E_TEST_COMMAND_TIMEOUT "%s": %v`, value, err)
+		} else {
+			timeout = parsed
+		}
+	}
+	return timeout
+}
+
+// WithTimeout instructs the command should be killed if it runs longer than the
+// timeout, 0 disables the timeout. Every retry of the command gets the timeout, the
+// commands started in the background ignore it.
+func (c CLI) WithTimeout(timeout time.Duration) *CLI {
+	c.timeout = &timeout
+	return &c
+}
+
+// WithContext instructs the command should be killed when the ctx is done, including
+// the commands started by Background.
+func (c CLI) WithContext(ctx context.Context) *CLI {
+	c.ctx = ctx
+	return &c
+}
+
+// parentContext returns the context of the CLI, see WithContext.
+func (c *CLI) parentContext() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
+}
+
+// commandTimeout returns the timeout of the command.
+func (c *CLI) commandTimeout() time.Duration {
+	if c.timeout != nil {
+		return *c.timeout
+	}
+	return DefaultCommandTimeout()
+}
+
+// commandContext returns the context of one run of the command, see WithTimeout.
+func (c *CLI) commandContext() (context.Context, context.CancelFunc) {
+	if timeout := c.commandTimeout(); timeout > 0 {
+		return context.WithTimeout(c.parentContext(), timeout)
+	}
+	return context.WithCancel(c.parentContext())
+}
+
+// TimeoutError is the error of a command killed because it timed out or its context
+// was cancelled, with the output it produced until then.
+type TimeoutError struct {
+	Cmd     string
+	Timeout time.Duration
+	Output  string
+	// Err is the error of the context, context.DeadlineExceeded on a timeout
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	reason := fmt.Sprintf("hung and was killed after %s", e.Timeout)
+	if e.Err != context.DeadlineExceeded {
+		reason = fmt.Sprintf("was killed: %v", e.Err)
+	}
+	return fmt.Sprintf("command '%s' %s, output so far:\n%s", e.Cmd, reason, redact.String(e.Output))
+}
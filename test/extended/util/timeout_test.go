@@ -0,0 +1,125 @@
+package util
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultCommandTimeout(t *testing.T) {
+	for value, want := range map[string]time.Duration{
+		"":        10 * time.Minute,
+		"2m":      2 * time.Minute,
+		"0":       0,
+		"invalid": 10 * time.Minute,
+	} {
+		t.Setenv("E2E_TEST_COMMAND_TIMEOUT", value)
+		if got := DefaultCommandTimeout(); got != want {
+			t.Errorf("%q: expected %s, got %s", value, want, got)
+		}
+	}
+}
+
+func TestCLI_Output_timeout(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name        string
+		env         string
+		cli         func(k *CLI) *CLI
+		wantTimeout time.Duration
+		wantErr     error
+		wantMessage string
+	}{
+		{
+			name:        "timeout",
+			cli:         func(k *CLI) *CLI { return k.WithTimeout(50 * time.Millisecond) },
+			wantTimeout: 50 * time.Millisecond,
+			wantErr:     context.DeadlineExceeded,
+			wantMessage: "command 'kubectl get pods --watch --token=REDACTED' hung and was killed after 50ms, output so far:\nNAME    READY --token=REDACTED",
+		},
+		{
+			name:        "default timeout from the environment",
+			env:         "50ms",
+			cli:         func(k *CLI) *CLI { return k },
+			wantTimeout: 50 * time.Millisecond,
+			wantErr:     context.DeadlineExceeded,
+			wantMessage: "hung and was killed after 50ms",
+		},
+		{
+			name:        "cancelled",
+			cli:         func(k *CLI) *CLI { return k.WithTimeout(time.Hour).WithContext(cancelled) },
+			wantTimeout: time.Hour,
+			wantErr:     context.Canceled,
+			wantMessage: "was killed: context canceled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("E2E_TEST_COMMAND_TIMEOUT", tt.env)
+			fake := &FakeExecutor{}
+			fake.Expect("get", "pods", "--watch", "...").Returns("NAME    READY --token=abc").Hangs()
+			k := tt.cli(NewCLIWithExecutor(fake).WithoutNamespace().WithoutKubeconf().WithoutWorkSpaceServer())
+
+			done := make(chan error)
+			go func() {
+				_, err := k.Run("get").Args("pods", "--watch", "--token=abc").Output()
+				done <- err
+			}()
+			var err error
+			select {
+			case err = <-done:
+			case <-time.After(30 * time.Second):
+				t.Fatal("the command was not killed")
+			}
+			timeoutErr, ok := err.(*TimeoutError)
+			if !ok {
+				t.Fatalf("expected a timeout error, got %v", err)
+			}
+			if timeoutErr.Timeout != tt.wantTimeout || timeoutErr.Err != tt.wantErr || timeoutErr.Output != "NAME    READY --token=abc" {
+				t.Errorf("unexpected timeout error %#v", timeoutErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantMessage) {
+				t.Errorf("unexpected message %q", err.Error())
+			}
+			// a command that timed out is not retried
+			if len(fake.Invocations) != 1 {
+				t.Errorf("unexpected invocations: %v", fake.Invocations)
+			}
+		})
+	}
+}
+
+// deadlineExecutor records whether the commands started in the background have a deadline.
+type deadlineExecutor struct {
+	*FakeExecutor
+	deadlines []bool
+}
+
+func (e *deadlineExecutor) Start(ctx context.Context, name string, args []string, stdin io.Reader, stdout, stderr io.Writer) (*exec.Cmd, error) {
+	_, ok := ctx.Deadline()
+	e.deadlines = append(e.deadlines, ok)
+	return e.FakeExecutor.Start(ctx, name, args, stdin, stdout, stderr)
+}
+
+func TestCLI_Background_timeout(t *testing.T) {
+	t.Setenv("E2E_TEST_COMMAND_TIMEOUT", "1ms")
+	executor := &deadlineExecutor{FakeExecutor: &FakeExecutor{}}
+	executor.Expect("logs", "-f", "...").Returns("line").Hangs()
+	k := NewCLIWithExecutor(executor).WithoutNamespace().WithoutKubeconf().WithoutWorkSpaceServer()
+
+	if _, _, _, err := k.Run("logs").Args("-f", "deployment/syncer").Background(); err != nil {
+		t.Fatal(err)
+	}
+	_, stdout, err := k.WithTimeout(time.Millisecond).Run("logs").Args("-f", "deployment/syncer").BackgroundRC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout.Close()
+	if len(executor.deadlines) != 2 || executor.deadlines[0] || executor.deadlines[1] {
+		t.Errorf("expected the commands in the background to run without a deadline, got %v", executor.deadlines)
+	}
+}
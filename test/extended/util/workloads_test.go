@@ -0,0 +1,81 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeployment_CheckReady(t *testing.T) {
+	tests := []struct {
+		name          string
+		script        func(fake *FakeExecutor)
+		want          bool
+		wantErr       bool
+		wantAssertion string
+	}{
+		{
+			name: "ready",
+			script: func(fake *FakeExecutor) {
+				fake.Expect("get", "deployment", "web", "-n", "default", "-o", "jsonpath={.spec.replicas}", "...").Returns("3")
+				fake.Expect("get", "deployment", "web", "-n", "default", "-o", "jsonpath={.status.availableReplicas}", "...").Returns("3")
+			},
+			want: true,
+		},
+		{
+			name: "not ready",
+			script: func(fake *FakeExecutor) {
+				fake.Expect("get", "deployment", "web", "-n", "default", "-o", "jsonpath={.spec.replicas}", "...").Returns("3")
+				fake.Expect("get", "deployment", "web", "-n", "default", "-o", "jsonpath={.status.availableReplicas}", "...").Returns("1")
+			},
+		},
+		{
+			name: "scaled to zero",
+			script: func(fake *FakeExecutor) {
+				fake.Expect("get", "deployment", "web", "-n", "default", "-o", "jsonpath={.spec.replicas}", "...").Returns("0")
+				fake.Expect("get", "deployment", "web", "-n", "default", "-o", "jsonpath={.status.availableReplicas}", "...")
+			},
+			want: true,
+		},
+		{
+			name: "available replicas unknown",
+			script: func(fake *FakeExecutor) {
+				fake.Expect("get", "deployment", "web", "-n", "default", "-o", "jsonpath={.spec.replicas}", "...").Returns("3")
+				fake.Expect("get", "deployment", "web", "-n", "default", "-o", "jsonpath={.status.availableReplicas}", "...").Fails(1, "error: the server doesn't have a resource type \"deployment\"")
+			},
+			wantErr: true,
+		},
+		{
+			name: "replicas unknown",
+			script: func(fake *FakeExecutor) {
+				fake.Expect("get", "deployment", "web", "-n", "default", "-o", "jsonpath={.spec.replicas}", "...").Fails(1, `Error from server (NotFound): deployments.apps "web" not found`)
+			},
+			wantAssertion: "exit status 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &FakeExecutor{}
+			tt.script(fake)
+			k := NewCLIWithExecutor(fake)
+			dep := &Deployment{Name: "web", Namespace: "default"}
+
+			var (
+				got bool
+				err error
+			)
+			failure := assertionFailure(func() { got, err = dep.CheckReady(k) })
+			if tt.wantAssertion != "" || failure != "" {
+				if tt.wantAssertion == "" || !strings.Contains(failure, tt.wantAssertion) {
+					t.Fatalf("unexpected assertion failure %q", failure)
+				}
+				return
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected ready %t, got %t", tt.want, got)
+			}
+		})
+	}
+}
@@ -1,13 +1,21 @@
 package util
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	o "github.com/onsi/gomega"
 	"k8s.io/apiserver/pkg/storage/names"
 	e2e "k8s.io/kubernetes/test/e2e/framework"
+
+	"github.com/kcp-dev/kcp-tests/test/extended/util/kcp"
 )
 
+// DefaultWorkSpaceReadyTimeout is how long SetupWorkSpace waits for a workspace to be ready
+const DefaultWorkSpaceReadyTimeout = 3 * time.Minute
+
 // WorkSpace definition
 type WorkSpace struct {
 	Name             string   // WorkSpace Name                                                E.g. e2e-test-kcp-workspace-xxxxx
@@ -15,6 +23,7 @@ type WorkSpace struct {
 	Namespaces       []string // WorkSpace's Namespaces created by SetupNameSpace()            E.g. e2e-ns-kcp-workspace-xxxxx
 	ServerURL        string   // WorkSpace ServerURL                                           E.g. https://{{kcp-service-domain}}/clusters/root:orgID:e2e-test-kcp-workspace-xxxxx
 	ParentServerURL  string   // WorkSpace ParentServerURL                                     E.g. https://{{kcp-service-domain}}/clusters/root:orgID
+	Context          string   // WorkSpace context in the test kubeconfig                      E.g. root:orgID:e2e-test-kcp-workspace-xxxxx
 }
 
 // SetNamespace creates a new namespace with
@@ -22,9 +31,144 @@ type WorkSpace struct {
 func (ws *WorkSpace) SetNamespace(c *CLI) {
 	newNamespace := names.SimpleNameGenerator.GenerateName(fmt.Sprintf("e2e-ns-%s-", c.kubeFramework.BaseName))
 	e2e.Logf("Creating namespace %q", newNamespace)
+	recordTestResource(newNamespace)
 	output, errinfo := c.WithoutNamespace().WithoutKubeconf().Run("create").Args("namespace", newNamespace).Output()
 	o.Expect(errinfo).NotTo(o.HaveOccurred())
 	o.Expect(output).Should(o.ContainSubstring("created"))
 	ws.CurrentNameSpace = newNamespace
 	ws.Namespaces = append(c.currentWorkSpace.Namespaces, newNamespace)
 }
+
+// WorkSpaceOption uses function option mode to change the default values of the workspaces
+// created by SetupWorkSpaceWithOptions and SetupWorkSpaceWithSpecificPath
+type WorkSpaceOption func(*workSpaceOptions)
+
+type workSpaceOptions struct {
+	parentServerURL string
+	typePath        string
+	typeName        string
+	labels          map[string]string
+	annotations     map[string]string
+	enter           bool
+	readyTimeout    time.Duration
+}
+
+// SetWorkSpaceParent sets the server URL of the workspace to create the workspace in,
+// the user's home workspace by default
+func SetWorkSpaceParent(serverURL string) WorkSpaceOption {
+	return func(options *workSpaceOptions) {
+		options.parentServerURL = serverURL
+	}
+}
+
+// SetWorkSpaceType sets the ClusterWorkspaceType of the workspace, E.g. ("root", "universal"),
+// ("root", "organization") or a custom type ("root:orgID:e2e-test-kcp-workspace-xxxxx", "mytype")
+func SetWorkSpaceType(path, name string) WorkSpaceOption {
+	return func(options *workSpaceOptions) {
+		options.typePath = path
+		options.typeName = name
+	}
+}
+
+// SetWorkSpaceLabels sets the labels of the workspace
+func SetWorkSpaceLabels(labels map[string]string) WorkSpaceOption {
+	return func(options *workSpaceOptions) {
+		options.labels = labels
+	}
+}
+
+// SetWorkSpaceAnnotations sets the annotations of the workspace
+func SetWorkSpaceAnnotations(annotations map[string]string) WorkSpaceOption {
+	return func(options *workSpaceOptions) {
+		options.annotations = annotations
+	}
+}
+
+// SetWorkSpaceEnter sets whether the workspace becomes the current workspace of the CLI
+// and the current context of the test kubeconfig once ready, true by default. The
+// workspace has a context in the test kubeconfig either way, so it can be entered later
+// with SetWorkSpace
+func SetWorkSpaceEnter(enter bool) WorkSpaceOption {
+	return func(options *workSpaceOptions) {
+		options.enter = enter
+	}
+}
+
+// SetWorkSpaceReadyTimeout sets how long to wait for the workspace phase to be Ready,
+// DefaultWorkSpaceReadyTimeout by default
+func SetWorkSpaceReadyTimeout(timeout time.Duration) WorkSpaceOption {
+	return func(options *workSpaceOptions) {
+		options.readyTimeout = timeout
+	}
+}
+
+// SetupWorkSpaceWithOptions creates a new WorkSpace under the user's home workspace by
+// default, waits for it to be ready and returns it. E.g.
+//
+//	ws := k.SetupWorkSpaceWithOptions(exutil.SetWorkSpaceType("root", "universal"), exutil.SetWorkSpaceEnter(false))
+func (c *CLI) SetupWorkSpaceWithOptions(opts ...WorkSpaceOption) WorkSpace {
+	options := c.newWorkSpaceOptions(opts...)
+	newWorkSpace := names.SimpleNameGenerator.GenerateName(fmt.Sprintf("e2e-test-%s-", c.kubeFramework.BaseName))
+	e2e.Logf("Creating workspace %q", newWorkSpace)
+	recordTestResource(newWorkSpace)
+	manifestJSON, err := workSpaceManifest(newWorkSpace, options)
+	o.Expect(err).NotTo(o.HaveOccurred())
+	ws := &WorkSpace{Name: newWorkSpace, ParentServerURL: options.parentServerURL, ServerURL: options.parentServerURL + ":" + newWorkSpace}
+	// Add the workspace to teardown deleted list, even if its creation fails or it never gets ready
+	c.workSpacesToDelete = append(c.workSpacesToDelete, ws)
+	retry := DefaultRetryPolicy()
+	retry.NonIdempotent = true
+	output, errinfo := c.WithRetry(retry).WithoutNamespace().WithoutKubeconf().WithoutWorkSpaceServer().Run("create").Args("-f", "-", "--server="+options.parentServerURL).InputString(string(manifestJSON)).Output()
+	// the name is generated and unique, so the workspace already exists only if an attempt
+	// failed with a transient error after the server created it
+	if errinfo != nil && strings.Contains(output, "(AlreadyExists)") {
+		e2e.Logf("Workspace %q was created by a failed attempt", ws.Name)
+	} else {
+		o.Expect(errinfo).NotTo(o.HaveOccurred())
+		o.Expect(output).Should(o.ContainSubstring("created"))
+	}
+
+	e2e.Logf("Waiting for workspace %q to be ready", ws.Name)
+	ready, err := kcp.NewClient(c.LogicalClusterDynamicClient(options.parentServerURL)).Workspaces().WaitForPhase(newWorkSpace, kcp.WorkspacePhaseReady, options.readyTimeout)
+	o.Expect(err).NotTo(o.HaveOccurred(), workSpaceDiagnostics(ready))
+
+	o.Expect(c.addWorkSpaceContext(ws, options.enter)).NotTo(o.HaveOccurred())
+	if options.enter {
+		c.currentWorkSpace = ws
+	}
+	e2e.Logf("Workspace %q has been fully provisioned.", ws.Name)
+	return *ws
+}
+
+// newWorkSpaceOptions returns the options of a workspace created with opts.
+func (c *CLI) newWorkSpaceOptions(opts ...WorkSpaceOption) workSpaceOptions {
+	options := workSpaceOptions{
+		parentServerURL: c.homeServerURL,
+		enter:           true,
+		readyTimeout:    DefaultWorkSpaceReadyTimeout,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// workSpaceManifest returns the Workspace created by SetupWorkSpaceWithOptions as JSON.
+func workSpaceManifest(name string, options workSpaceOptions) ([]byte, error) {
+	manifest := kcp.NewWorkspace(name)
+	if options.typeName != "" {
+		manifest.SetType(options.typePath, options.typeName)
+	}
+	manifest.SetLabels(options.labels)
+	manifest.SetAnnotations(options.annotations)
+	return json.Marshal(manifest.Object)
+}
+
+// workSpaceDiagnostics describes why a workspace is not ready, E.g. the initializers
+// still pending while it is Initializing.
+func workSpaceDiagnostics(ws *kcp.Workspace) string {
+	if ws == nil {
+		return "the workspace could not be found"
+	}
+	return fmt.Sprintf("workspace %q of type %q is %q, initializers pending %v, conditions %v", ws.GetName(), ws.Type(), ws.Phase(), ws.Initializers(), ws.Conditions())
+}
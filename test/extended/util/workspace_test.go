@@ -0,0 +1,223 @@
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/kcp-dev/kcp-tests/test/extended/util/kcp"
+)
+
+func TestCLI_newWorkSpaceOptions(t *testing.T) {
+	k := NewCLIWithExecutor(&FakeExecutor{})
+	k.homeServerURL = "https://kcp.example.com/clusters/root:users:ab:cd:kcp-admin"
+
+	options := k.newWorkSpaceOptions()
+	if want := (workSpaceOptions{parentServerURL: k.homeServerURL, enter: true, readyTimeout: DefaultWorkSpaceReadyTimeout}); !reflect.DeepEqual(options, want) {
+		t.Errorf("unexpected default options %#v", options)
+	}
+
+	options = k.newWorkSpaceOptions(
+		SetWorkSpaceParent("https://kcp.example.com/clusters/root:org"),
+		SetWorkSpaceType("root", "universal"),
+		SetWorkSpaceLabels(map[string]string{"team": "e2e"}),
+		SetWorkSpaceAnnotations(map[string]string{"note": "test"}),
+		SetWorkSpaceEnter(false),
+		SetWorkSpaceReadyTimeout(time.Minute),
+	)
+	want := workSpaceOptions{
+		parentServerURL: "https://kcp.example.com/clusters/root:org",
+		typePath:        "root",
+		typeName:        "universal",
+		labels:          map[string]string{"team": "e2e"},
+		annotations:     map[string]string{"note": "test"},
+		readyTimeout:    time.Minute,
+	}
+	if !reflect.DeepEqual(options, want) {
+		t.Errorf("unexpected options %#v", options)
+	}
+}
+
+func Test_workSpaceManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		options workSpaceOptions
+		want    string
+	}{
+		{
+			name: "default",
+			want: `{"apiVersion":"tenancy.kcp.dev/v1beta1","kind":"Workspace","metadata":{"name":"e2e-test-kcp-a1b2c"}}`,
+		},
+		{
+			name: "type and metadata",
+			options: workSpaceOptions{
+				typePath:    "root:org",
+				typeName:    "mytype",
+				labels:      map[string]string{"team": "e2e"},
+				annotations: map[string]string{"note": "test"},
+			},
+			want: `{"apiVersion":"tenancy.kcp.dev/v1beta1","kind":"Workspace","metadata":{"annotations":{"note":"test"},"labels":{"team":"e2e"},"name":"e2e-test-kcp-a1b2c"},"spec":{"type":{"name":"mytype","path":"root:org"}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, err := workSpaceManifest("e2e-test-kcp-a1b2c", tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(manifest) != tt.want {
+				t.Errorf("unexpected manifest %s", manifest)
+			}
+		})
+	}
+}
+
+func Test_workSpaceDiagnostics(t *testing.T) {
+	if got := workSpaceDiagnostics(nil); got != "the workspace could not be found" {
+		t.Errorf("unexpected diagnostics of a missing workspace %q", got)
+	}
+	ws := kcp.NewWorkspace("a").SetType("root", "universal")
+	ws.Object["status"] = map[string]interface{}{
+		"phase":        "Initializing",
+		"initializers": []interface{}{"system:apibindings"},
+		"conditions":   []interface{}{map[string]interface{}{"type": "WorkspaceInitialized", "status": "False", "reason": "InitializerExists"}},
+	}
+	want := `workspace "a" of type "universal" is "Initializing", initializers pending [system:apibindings], conditions [{WorkspaceInitialized False InitializerExists }]`
+	if got := workSpaceDiagnostics(ws); got != want {
+		t.Errorf("unexpected diagnostics %q", got)
+	}
+}
+
+// workSpaceServer serves the workspaces of the logical clusters in the given phase.
+func workSpaceServer(phase string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodGet || !strings.Contains(r.URL.Path, "/apis/tenancy.kcp.dev/v1beta1/workspaces/") {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`)
+			return
+		}
+		fmt.Fprintf(w, `{"apiVersion":"tenancy.kcp.dev/v1beta1","kind":"Workspace","metadata":{"name":%q},"spec":{"type":{"name":"universal","path":"root"}},"status":{"phase":%q,"initializers":["system:apibindings"]}}`, path.Base(r.URL.Path), phase)
+	}))
+}
+
+func TestCLI_SetupWorkSpaceWithOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		enter       bool
+		wantCurrent bool
+	}{
+		{name: "entered", enter: true, wantCurrent: true},
+		{name: "not entered", enter: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := workSpaceServer(kcp.WorkspacePhaseReady)
+			defer server.Close()
+			fake := &FakeExecutor{}
+			fake.Expect("create", "-f", "-", "*").Returns("workspace.tenancy.kcp.dev/e2e-test-kcp-fake-a1b2c created")
+			k := NewCLIWithExecutor(fake)
+			k.adminConfigPath = setTestKubeconfig(t)
+			setTestContext(t, "kcp-stable-root")
+			defer k.removeWorkSpaceKubeconfig()
+			resources := path.Join(t.TempDir(), "resources")
+			t.Setenv("TEST_RESOURCES_FILE", resources)
+			previous := k.currentWorkSpace
+			parent := server.URL + "/clusters/root:org"
+
+			ws := k.SetupWorkSpaceWithOptions(SetWorkSpaceParent(parent), SetWorkSpaceEnter(tt.enter), SetWorkSpaceReadyTimeout(time.Second))
+			if !strings.HasPrefix(ws.Name, "e2e-test-kcp-fake-") || ws.ParentServerURL != parent || ws.ServerURL != parent+":"+ws.Name {
+				t.Errorf("unexpected workspace %#v", ws)
+			}
+			if want := [][]string{{"create", "-f", "-", "--server=" + parent}}; !reflect.DeepEqual(fake.Invocations, want) {
+				t.Errorf("unexpected invocations: %q", fake.Invocations)
+			}
+			if len(k.workSpacesToDelete) != 1 || k.workSpacesToDelete[0].Name != ws.Name {
+				t.Errorf("expected the workspace to be deleted at teardown, got %v", k.workSpacesToDelete)
+			}
+			if data, err := ioutil.ReadFile(resources); err != nil || string(data) != ws.Name+"\n" {
+				t.Errorf("expected the workspace to be recorded as a test resource, got %q: %v", data, err)
+			}
+
+			config, err := clientcmd.LoadFromFile(k.WorkSpaceConfigPath())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := config.Contexts[ws.Context]; !ok || ws.Context != "root:org:"+ws.Name {
+				t.Errorf("expected a context %q for the workspace, got %v", ws.Context, config.Contexts)
+			}
+			if tt.wantCurrent {
+				if k.WorkSpace().Name != ws.Name || config.CurrentContext != ws.Context {
+					t.Errorf("expected the workspace to be entered, got %q and context %q", k.WorkSpace().Name, config.CurrentContext)
+				}
+			} else if k.currentWorkSpace != previous || config.CurrentContext != "" {
+				t.Errorf("expected the workspace not to be entered, got %q and context %q", k.WorkSpace().Name, config.CurrentContext)
+			}
+		})
+	}
+}
+
+func TestCLI_SetupWorkSpaceWithOptions_notReady(t *testing.T) {
+	server := workSpaceServer(kcp.WorkspacePhaseInitializing)
+	defer server.Close()
+	fake := &FakeExecutor{}
+	fake.Expect("create", "...").Returns("workspace.tenancy.kcp.dev/e2e-test-kcp-fake-a1b2c created")
+	k := NewCLIWithExecutor(fake)
+	k.adminConfigPath = setTestKubeconfig(t)
+
+	failure := assertionFailure(func() {
+		k.SetupWorkSpaceWithOptions(SetWorkSpaceParent(server.URL+"/clusters/root:org"), SetWorkSpaceReadyTimeout(time.Millisecond))
+	})
+	if !strings.Contains(failure, `of type "universal" is "Initializing", initializers pending [system:apibindings]`) {
+		t.Errorf("expected the diagnostics of the workspace, got %q", failure)
+	}
+	if len(k.workSpacesToDelete) != 1 || k.WorkSpaceConfigPath() != "" {
+		t.Errorf("expected the workspace to be deleted at teardown without a context, got %v and %q", k.workSpacesToDelete, k.WorkSpaceConfigPath())
+	}
+}
+
+func TestCLI_SetupWorkSpaceWithOptions_retried(t *testing.T) {
+	t.Setenv("E2E_TEST_RETRY_INTERVAL", "1ms")
+	server := workSpaceServer(kcp.WorkspacePhaseReady)
+	defer server.Close()
+	fake := &FakeExecutor{}
+	// the first attempt created the workspace before failing
+	fake.Expect("create", "...").Fails(1, "Error from server: etcdserver: request timed out").Once()
+	fake.Expect("create", "...").Fails(1, `Error from server (AlreadyExists): workspaces.tenancy.kcp.dev "e2e-test-kcp-fake-a1b2c" already exists`)
+	k := NewCLIWithExecutor(fake)
+	k.adminConfigPath = setTestKubeconfig(t)
+	defer k.removeWorkSpaceKubeconfig()
+
+	ws := k.SetupWorkSpaceWithOptions(SetWorkSpaceParent(server.URL+"/clusters/root:org"), SetWorkSpaceReadyTimeout(time.Second))
+	if len(fake.Invocations) != 2 || k.WorkSpace().Name != ws.Name {
+		t.Errorf("expected the workspace to be set up after the retry, got %q", fake.Invocations)
+	}
+	if len(k.workSpacesToDelete) != 1 || k.workSpacesToDelete[0].Name != ws.Name {
+		t.Errorf("expected the workspace to be deleted at teardown, got %v", k.workSpacesToDelete)
+	}
+}
+
+func TestCLI_SetupWorkSpaceWithOptions_createFails(t *testing.T) {
+	fake := &FakeExecutor{}
+	fake.Expect("create", "...").Fails(1, `Error from server (Forbidden): workspaces.tenancy.kcp.dev is forbidden`)
+	k := NewCLIWithExecutor(fake)
+	k.adminConfigPath = setTestKubeconfig(t)
+
+	failure := assertionFailure(func() {
+		k.SetupWorkSpaceWithOptions(SetWorkSpaceParent("https://kcp.example.com/clusters/root:org"))
+	})
+	if len(failure) == 0 {
+		t.Error("expected the setup to fail")
+	}
+	if len(k.workSpacesToDelete) != 1 || !strings.HasPrefix(k.workSpacesToDelete[0].Name, "e2e-test-kcp-fake-") {
+		t.Errorf("expected the workspace to be deleted at teardown even if its creation failed, got %v", k.workSpacesToDelete)
+	}
+}
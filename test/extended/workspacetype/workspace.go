@@ -82,27 +82,22 @@ var _ = g.Describe("[area/workspaces]", func() {
 	})
 
 	// author: zxiao@redhat.com
-	g.It("Author:zxiao-Medium-[Serial] I can create context for a specific workspace and use it", func() {
+	g.It("Author:zxiao-Medium-I can create context for a specific workspace and use it", func() {
 		g.By("# Create a test workspace")
 		k.SetupWorkSpace()
 		workSpace := k.WorkSpace()
 
-		g.By("# Get context before test run")
-		contextBeforeTestRun, err := k.Run("config").Args("current-context").Output()
+		g.By("# Check the context of the test workspace is the current one")
+		workSpaceContext, err := k.Run("config").Args("current-context").Output()
 		o.Expect(err).NotTo(o.HaveOccurred())
+		o.Expect(workSpaceContext).To(o.Equal(workSpace.Context))
 
 		g.By("# Create context under this workspace")
 		err = k.WithoutWorkSpaceServer().Run("kcp").Args("workspace", "create-context", workSpace.Name, "--server="+workSpace.ParentServerURL).Execute()
 		o.Expect(err).NotTo(o.HaveOccurred())
 
 		g.By("# Switch to the newly created context")
-		// NOTE: not doing assertion as to avoid double assertion failures, use DeferCleanup in ginkgo v2.0 in the future
-		defer func() {
-			err = k.Run("config").Args("use-context", contextBeforeTestRun).Execute()
-			o.Expect(err).NotTo(o.HaveOccurred())
-			k.Run("config").Args("delete-context", workSpace.Name).Execute()
-		}()
-
+		// the contexts are in the kubeconfig of the test, removed with its workspaces
 		err = k.Run("config").Args("use-context", workSpace.Name).Execute()
 		o.Expect(err).NotTo(o.HaveOccurred())
 